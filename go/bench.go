@@ -0,0 +1,136 @@
+package tap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// benchResult holds the metrics of one benchmark result line.
+type benchResult struct {
+	iterations string
+	procs      string
+	units      []string
+	values     map[string]float64
+}
+
+// BenchmarkFoo-8   	 1000	      2.477 ns/op	   3.500 widgets/op	   0 B/op
+var benchLineRe = regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?\s+(\d+)\s+(.+)$`)
+
+// parseBenchLine parses a benchmark result line, returning the benchmark's
+// name without its GOMAXPROCS suffix.
+func parseBenchLine(line string) (string, *benchResult, bool) {
+	m := benchLineRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(m[4])
+	if len(fields) < 2 || len(fields)%2 != 0 {
+		return "", nil, false
+	}
+	res := &benchResult{iterations: m[3], procs: m[2], values: make(map[string]float64)}
+	for i := 0; i < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return "", nil, false
+		}
+		res.units = append(res.units, fields[i+1])
+		res.values[fields[i+1]] = v
+	}
+	return m[1], res, true
+}
+
+// diagnostics returns the benchmark's metrics as YAML diagnostics keyed by
+// unit, e.g. "ns/op", along with its iteration count.
+func (b *benchResult) diagnostics() map[string]string {
+	diag := map[string]string{"iterations": b.iterations}
+	if b.procs != "" {
+		diag["procs"] = b.procs
+	}
+	for _, unit := range b.units {
+		diag[unit] = strconv.FormatFloat(b.values[unit], 'f', -1, 64)
+	}
+	return diag
+}
+
+// BenchThreshold limits one metric of the benchmarks whose names match
+// Pattern, a path.Match pattern such as "BenchmarkParse/*".
+type BenchThreshold struct {
+	Pattern string
+	Unit    string
+	Op      string // <, <=, >, >=
+	Limit   float64
+}
+
+// ParseBenchThresholds reads benchmark thresholds, one per line, as
+//
+//	PATTERN UNIT OP LIMIT
+//
+// for example "BenchmarkParse ns/op <= 5000" or "BenchmarkCopy/* MB/s >= 900".
+// Blank lines and lines starting with # are ignored.
+func ParseBenchThresholds(r io.Reader) ([]BenchThreshold, error) {
+	var thresholds []BenchThreshold
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected PATTERN UNIT OP LIMIT", lineNum)
+		}
+		if _, err := path.Match(fields[0], ""); err != nil {
+			return nil, fmt.Errorf("line %d: bad pattern %q: %w", lineNum, fields[0], err)
+		}
+		switch fields[2] {
+		case "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("line %d: unknown operator %q", lineNum, fields[2])
+		}
+		limit, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad limit %q", lineNum, fields[3])
+		}
+		thresholds = append(thresholds, BenchThreshold{Pattern: fields[0], Unit: fields[1], Op: fields[2], Limit: limit})
+	}
+	return thresholds, scanner.Err()
+}
+
+// check returns a message for each threshold the benchmark breaks.
+func (b *benchResult) check(name string, thresholds []BenchThreshold) []string {
+	var broken []string
+	for _, t := range thresholds {
+		if ok, _ := path.Match(t.Pattern, name); !ok {
+			continue
+		}
+		v, ok := b.values[t.Unit]
+		if !ok {
+			continue
+		}
+		var within bool
+		switch t.Op {
+		case "<":
+			within = v < t.Limit
+		case "<=":
+			within = v <= t.Limit
+		case ">":
+			within = v > t.Limit
+		case ">=":
+			within = v >= t.Limit
+		}
+		if !within {
+			broken = append(broken, fmt.Sprintf("%s %s is not %s %s",
+				t.Unit, strconv.FormatFloat(v, 'f', -1, 64), t.Op, strconv.FormatFloat(t.Limit, 'f', -1, 64)))
+		}
+	}
+	return broken
+}
@@ -0,0 +1,98 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBenchLine(t *testing.T) {
+	name, res, ok := parseBenchLine("BenchmarkCopy/large-8   \t    1000\t      2.477 ns/op\t 412.50 MB/s\t   3.500 widgets/op\t       0 B/op\t       0 allocs/op\n")
+	if !ok {
+		t.Fatal("expected benchmark line to parse")
+	}
+	if name != "BenchmarkCopy/large" {
+		t.Errorf("name = %q, want BenchmarkCopy/large", name)
+	}
+
+	diag := res.diagnostics()
+	want := map[string]string{
+		"iterations": "1000",
+		"procs":      "8",
+		"ns/op":      "2.477",
+		"MB/s":       "412.5",
+		"widgets/op": "3.5",
+		"B/op":       "0",
+		"allocs/op":  "0",
+	}
+	for k, v := range want {
+		if diag[k] != v {
+			t.Errorf("diag[%q] = %q, want %q", k, diag[k], v)
+		}
+	}
+}
+
+func TestParseBenchLineRejectsOtherOutput(t *testing.T) {
+	for _, line := range []string{
+		"BenchmarkFoo\n",
+		"=== RUN   BenchmarkFoo\n",
+		"BenchmarkFoo-8 \t 1000\t fast ns/op\n",
+		"goos: linux\n",
+		"PASS\n",
+	} {
+		if _, _, ok := parseBenchLine(line); ok {
+			t.Errorf("parseBenchLine(%q) matched", line)
+		}
+	}
+}
+
+func TestParseBenchThresholds(t *testing.T) {
+	input := `# limits for CI
+BenchmarkParse ns/op <= 5000
+
+BenchmarkCopy/* MB/s >= 900
+`
+	thresholds, err := ParseBenchThresholds(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BenchThreshold{
+		{Pattern: "BenchmarkParse", Unit: "ns/op", Op: "<=", Limit: 5000},
+		{Pattern: "BenchmarkCopy/*", Unit: "MB/s", Op: ">=", Limit: 900},
+	}
+	if len(thresholds) != len(want) {
+		t.Fatalf("got %d thresholds, want %d", len(thresholds), len(want))
+	}
+	for i := range want {
+		if thresholds[i] != want[i] {
+			t.Errorf("threshold %d = %+v, want %+v", i, thresholds[i], want[i])
+		}
+	}
+}
+
+func TestParseBenchThresholdsErrors(t *testing.T) {
+	for _, input := range []string{
+		"BenchmarkParse ns/op 5000\n",
+		"BenchmarkParse ns/op == 5000\n",
+		"BenchmarkParse ns/op <= fast\n",
+		"Benchmark[ ns/op <= 5000\n",
+	} {
+		if _, err := ParseBenchThresholds(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestBenchCheck(t *testing.T) {
+	_, res, _ := parseBenchLine("BenchmarkCopy/large-8 \t 1000\t 6000 ns/op\t 800 MB/s\n")
+	thresholds := []BenchThreshold{
+		{Pattern: "BenchmarkCopy/*", Unit: "ns/op", Op: "<=", Limit: 5000},
+		{Pattern: "BenchmarkCopy/*", Unit: "MB/s", Op: ">=", Limit: 500},
+		{Pattern: "BenchmarkCopy/*", Unit: "allocs/op", Op: "<", Limit: 1},
+		{Pattern: "BenchmarkParse", Unit: "ns/op", Op: "<", Limit: 1},
+	}
+
+	broken := res.check("BenchmarkCopy/large", thresholds)
+	if len(broken) != 1 || broken[0] != "ns/op 6000 is not <= 5000" {
+		t.Errorf("broken = %q, want [ns/op 6000 is not <= 5000]", broken)
+	}
+}
@@ -0,0 +1,274 @@
+package tap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type cargoEvent struct {
+	Type     string  `json:"type"`
+	Event    string  `json:"event"`
+	Name     string  `json:"name"`
+	Stdout   string  `json:"stdout"`
+	Message  string  `json:"message"`
+	ExecTime float64 `json:"exec_time"`
+}
+
+type cargoTestResult struct {
+	name    string
+	action  string // ok, failed, ignored
+	reason  string
+	elapsed float64
+	output  strings.Builder
+}
+
+type cargoBinary struct {
+	name    string
+	tests   []*cargoTestResult
+	testMap map[string]*cargoTestResult
+	failed  bool
+	elapsed float64
+}
+
+func (b *cargoBinary) test(name string) *cargoTestResult {
+	tr := b.testMap[name]
+	if tr == nil {
+		tr = &cargoTestResult{name: name}
+		b.testMap[name] = tr
+		b.tests = append(b.tests, tr)
+	}
+	return tr
+}
+
+var (
+	cargoRunningRe     = regexp.MustCompile(`^\s*Running (.+) \((.+)\)$`)
+	cargoDocTestsRe    = regexp.MustCompile(`^\s*Doc-tests (\S+)$`)
+	cargoTestLineRe    = regexp.MustCompile(`^test (.+?) \.\.\. (ok|FAILED|ignored(?:, (.*))?|bench:.*)$`)
+	cargoOutputStartRe = regexp.MustCompile(`^---- (.+) std(out|err) ----$`)
+	cargoResultRe      = regexp.MustCompile(`^test result: (ok|FAILED)\..*finished in ([\d.]+)s`)
+	cargoBuildFailRe   = regexp.MustCompile(`^error: could not compile (.*)$`)
+	cargoBinaryHashRe  = regexp.MustCompile(`-[0-9a-f]+$`)
+
+	// panicked at src/lib.rs:8:46:\nmessage (Rust 1.73+)
+	panicNewRe = regexp.MustCompile(`(?m)^thread '.*' panicked at ([^:\n]+):(\d+):(\d+):$`)
+	// panicked at 'message', src/lib.rs:8:46 (older releases)
+	panicOldRe = regexp.MustCompile(`(?m)^thread '.*' panicked at '(.*)', ([^:\n]+):(\d+):(\d+)$`)
+)
+
+// ConvertCargoTest reads cargo test output from r and writes TAP-14 to w.
+// The input is cargo's stdout and stderr merged in order, so that the
+// "Running" lines naming each test binary precede its results. Both libtest's
+// plain format and its JSON format (-Z unstable-options --format json) are
+// understood. Each test binary becomes a subtest and ignored tests become
+// SKIP. If verbose is true, passing tests include their captured output.
+// Returns an exit code: 0 for all pass, 1 for any failure, 2 for build errors.
+func ConvertCargoTest(r io.Reader, w io.Writer, verbose bool) int {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	tw := NewWriter(w)
+	exitCode := 0
+
+	var bin *cargoBinary
+	var capturing *cargoTestResult
+	var buildOutput strings.Builder
+
+	startBinary := func(name string) {
+		if bin != nil {
+			// The previous binary never printed its result line, so it
+			// crashed or was killed.
+			bin.failed = true
+			emitCargoBinary(tw, bin, verbose)
+			exitCode = max(exitCode, 1)
+		}
+		bin = &cargoBinary{name: name, testMap: make(map[string]*cargoTestResult)}
+		capturing = nil
+		buildOutput.Reset()
+	}
+
+	finishBinary := func(failed bool, elapsed float64) {
+		bin.failed = bin.failed || failed
+		bin.elapsed = elapsed
+		emitCargoBinary(tw, bin, verbose)
+		if bin.failed {
+			exitCode = max(exitCode, 1)
+		}
+		bin = nil
+		capturing = nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := cargoRunningRe.FindStringSubmatch(line); m != nil {
+			crate := cargoBinaryHashRe.ReplaceAllString(filepath.Base(m[2]), "")
+			startBinary(crate + " " + m[1])
+			continue
+		}
+		if m := cargoDocTestsRe.FindStringSubmatch(line); m != nil {
+			startBinary(m[1] + " doc-tests")
+			continue
+		}
+		if m := cargoBuildFailRe.FindStringSubmatch(line); m != nil {
+			tw.NotOk("could not compile "+m[1], map[string]string{
+				"message": strings.TrimSpace(buildOutput.String()),
+			})
+			buildOutput.Reset()
+			exitCode = 2
+			continue
+		}
+
+		if bin == nil {
+			// Outside any test binary, keep compiler errors for a
+			// possible build failure.
+			if strings.HasPrefix(line, "error") || buildOutput.Len() > 0 {
+				buildOutput.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "{") {
+			var ev cargoEvent
+			if err := json.Unmarshal([]byte(line), &ev); err == nil {
+				switch ev.Type {
+				case "test":
+					if ev.Event == "started" || ev.Event == "timeout" {
+						continue
+					}
+					tr := bin.test(ev.Name)
+					tr.action = ev.Event
+					tr.reason = ev.Message
+					tr.elapsed = ev.ExecTime
+					tr.output.WriteString(ev.Stdout)
+				case "bench":
+					bin.test(ev.Name).action = "ok"
+				case "suite":
+					if ev.Event == "ok" || ev.Event == "failed" {
+						finishBinary(ev.Event == "failed", ev.ExecTime)
+					}
+				}
+				continue
+			}
+		}
+
+		if m := cargoTestLineRe.FindStringSubmatch(line); m != nil {
+			name := strings.TrimSuffix(m[1], " - should panic")
+			tr := bin.test(name)
+			switch {
+			case m[2] == "FAILED":
+				tr.action = "failed"
+			case strings.HasPrefix(m[2], "ignored"):
+				tr.action = "ignored"
+				tr.reason = m[3]
+			default:
+				tr.action = "ok"
+			}
+			continue
+		}
+
+		if m := cargoOutputStartRe.FindStringSubmatch(line); m != nil {
+			capturing = bin.test(m[1])
+			continue
+		}
+
+		if m := cargoResultRe.FindStringSubmatch(line); m != nil {
+			var elapsed float64
+			fmt.Sscanf(m[2], "%g", &elapsed)
+			finishBinary(m[1] == "FAILED", elapsed)
+			continue
+		}
+
+		if line == "failures:" || line == "successes:" {
+			capturing = nil
+			continue
+		}
+
+		if capturing != nil {
+			capturing.output.WriteString(line + "\n")
+		}
+	}
+
+	if bin != nil {
+		bin.failed = true
+		emitCargoBinary(tw, bin, verbose)
+		exitCode = max(exitCode, 1)
+	}
+
+	tw.Plan()
+	return exitCode
+}
+
+func emitCargoBinary(tw *Writer, bin *cargoBinary, verbose bool) {
+	sub := tw.Subtest(bin.name)
+
+	for _, tr := range bin.tests {
+		output := strings.TrimSpace(tr.output.String())
+
+		switch tr.action {
+		case "ok":
+			if verbose && output != "" {
+				sub.OkWithDiagnostics(tr.name, map[string]string{"output": output})
+			} else {
+				sub.Ok(tr.name)
+			}
+		case "ignored":
+			reason := tr.reason
+			if reason == "" {
+				reason = "ignored"
+			}
+			sub.Skip(tr.name, reason)
+		default:
+			bin.failed = true
+			diag := map[string]string{
+				"binary": bin.name,
+			}
+			if tr.elapsed > 0 {
+				diag["elapsed"] = fmt.Sprintf("%.3f", tr.elapsed)
+			}
+			message, file, line := parsePanic(output)
+			if file != "" {
+				diag["file"] = file
+				diag["line"] = line
+				diag["message"] = message
+				diag["output"] = output
+			} else if output != "" {
+				diag["message"] = output
+			}
+			sub.NotOk(tr.name, diag)
+		}
+	}
+
+	sub.Plan()
+
+	if bin.failed {
+		tw.NotOk(bin.name, nil)
+	} else {
+		tw.Ok(bin.name)
+	}
+}
+
+// parsePanic extracts the panic message and location from a failed test's
+// captured output.
+func parsePanic(output string) (message, file, line string) {
+	if loc := panicNewRe.FindStringSubmatchIndex(output); loc != nil {
+		file = output[loc[2]:loc[3]]
+		line = output[loc[4]:loc[5]]
+		var msg []string
+		for _, l := range strings.Split(output[loc[1]:], "\n") {
+			if strings.HasPrefix(l, "note: ") || strings.HasPrefix(l, "stack backtrace:") {
+				break
+			}
+			msg = append(msg, l)
+		}
+		return strings.TrimSpace(strings.Join(msg, "\n")), file, line
+	}
+	if m := panicOldRe.FindStringSubmatch(output); m != nil {
+		return m[1], m[2], m[3]
+	}
+	return "", "", ""
+}
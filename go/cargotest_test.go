@@ -0,0 +1,144 @@
+package tap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertCargoTestPlain(t *testing.T) {
+	output := strings.Join([]string{
+		`   Compiling ct v0.1.0 (/tmp/ct)`,
+		`    Finished ` + "`test`" + ` profile [unoptimized + debuginfo] target(s) in 0.50s`,
+		`     Running unittests src/lib.rs (target/debug/deps/ct-4050dc35aef574e0)`,
+		``,
+		`running 4 tests`,
+		`test tests::bad ... FAILED`,
+		`test tests::good ... ok`,
+		`test tests::panics - should panic ... ok`,
+		`test tests::slow ... ignored, slow thing`,
+		``,
+		`failures:`,
+		``,
+		`---- tests::bad stdout ----`,
+		``,
+		`thread 'tests::bad' panicked at src/lib.rs:8:46:`,
+		"assertion `left == right` failed",
+		`  left: 3`,
+		` right: 4`,
+		"note: run with `RUST_BACKTRACE=1` environment variable to display a backtrace",
+		``,
+		``,
+		`failures:`,
+		`    tests::bad`,
+		``,
+		`test result: FAILED. 2 passed; 1 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.02s`,
+		``,
+		"error: test failed, to rerun pass `--lib`",
+		`   Doc-tests ct`,
+		``,
+		`running 1 test`,
+		`test src/lib.rs - add (line 1) ... ok`,
+		``,
+		`test result: ok. 1 passed; 0 failed; 0 ignored; 0 measured; 0 filtered out; finished in 0.12s`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertCargoTest(strings.NewReader(output), &buf, false)
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "# Subtest: ct unittests src/lib.rs") {
+		t.Errorf("expected test binary subtest:\n%s", out)
+	}
+	if !strings.Contains(out, "not ok 1 - ct unittests src/lib.rs") {
+		t.Errorf("expected failing binary:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 2 - ct doc-tests") {
+		t.Errorf("expected passing doc-tests binary:\n%s", out)
+	}
+	if !strings.Contains(out, "      file: src/lib.rs\n      line: 8\n") {
+		t.Errorf("expected panic location in diagnostics:\n%s", out)
+	}
+	if !strings.Contains(out, "      message: |\n        assertion `left == right` failed\n") {
+		t.Errorf("expected panic message in diagnostics:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 3 - tests::panics\n") {
+		t.Errorf("expected should-panic suffix stripped:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 4 - tests::slow # SKIP slow thing") {
+		t.Errorf("expected ignored test as SKIP:\n%s", out)
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertCargoTestJSON(t *testing.T) {
+	output := strings.Join([]string{
+		`     Running tests/integ.rs (target/debug/deps/integ-2971bea2420f96ae)`,
+		`{ "type": "suite", "event": "started", "test_count": 3 }`,
+		`{ "type": "test", "event": "started", "name": "integ_bad" }`,
+		`{ "type": "test", "name": "integ_bad", "event": "failed", "exec_time": 0.0042, "stdout": "\nthread 'integ_bad' panicked at tests/integ.rs:2:26:\ninteg failure 5\n" }`,
+		`{ "type": "test", "event": "started", "name": "integ_ok" }`,
+		`{ "type": "test", "name": "integ_ok", "event": "ok", "exec_time": 0.0001 }`,
+		`{ "type": "test", "name": "integ_slow", "event": "ignored" }`,
+		`{ "type": "suite", "event": "failed", "passed": 1, "failed": 1, "ignored": 1, "measured": 0, "filtered_out": 0, "exec_time": 0.005 }`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertCargoTest(strings.NewReader(output), &buf, false)
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "# Subtest: integ tests/integ.rs") {
+		t.Errorf("expected test binary subtest:\n%s", out)
+	}
+	if !strings.Contains(out, "      elapsed: 0.004\n") {
+		t.Errorf("expected exec_time as elapsed:\n%s", out)
+	}
+	if !strings.Contains(out, "      message: integ failure 5\n") {
+		t.Errorf("expected panic message:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 3 - integ_slow # SKIP ignored") {
+		t.Errorf("expected ignored test as SKIP:\n%s", out)
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertCargoTestBuildFailure(t *testing.T) {
+	output := strings.Join([]string{
+		`   Compiling ct v0.1.0 (/tmp/ct)`,
+		`error: this file contains an unclosed delimiter`,
+		` --> tests/integ.rs:3:9`,
+		``,
+		"error: could not compile `ct` (test \"integ\") due to 1 previous error",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertCargoTest(strings.NewReader(output), &buf, false)
+
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "not ok 1 - could not compile `ct`") {
+		t.Errorf("expected build failure test point:\n%s", out)
+	}
+	if !strings.Contains(out, " --> tests/integ.rs:3:9") {
+		t.Errorf("expected compiler error in diagnostics:\n%s", out)
+	}
+}
@@ -10,7 +10,11 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/command"
 	"github.com/amarbel-llc/purse-first/libs/go-mcp/server"
@@ -26,6 +30,16 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Commands:\n")
 		fmt.Fprintf(os.Stderr, "  validate              Validate TAP-14 input\n")
 		fmt.Fprintf(os.Stderr, "  go-test [args...]    Run go test and convert output to TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  cargo-test [args...] Run cargo test and convert output to TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  pytest [args...]      Run pytest and convert results to TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  from-junit [FILE...]  Convert JUnit XML reports to TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  to-junit              Convert TAP-14 on stdin to JUnit XML\n")
+		fmt.Fprintf(os.Stderr, "  github [FILE]         Print GitHub Actions annotations for TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  pretty [--dots] [FILE] Render TAP-14 for people as it arrives\n")
+		fmt.Fprintf(os.Stderr, "  teamcity [FILE]       Print TeamCity service messages for TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  summary --markdown [FILE] Render a Markdown summary of TAP-14\n")
+		fmt.Fprintf(os.Stderr, "  report --html [FILE...] Render TAP-14 streams as an HTML report\n")
+		fmt.Fprintf(os.Stderr, "  run [flags] SCRIPT... Run TAP-producing scripts as one TAP-14 stream\n")
 		fmt.Fprintf(os.Stderr, "  generate-plugin DIR   Generate MCP plugin (for Nix postInstall)\n")
 		fmt.Fprintf(os.Stderr, "\nWhen run with no args and no TTY, starts MCP server mode\n")
 	}
@@ -80,14 +94,24 @@ func registerCommands() *command.App {
 	app.Version = "0.1.0"
 
 	app.AddCommand(&command.Command{
-		Name:        "validate",
-		Description: command.Description{Short: "Validate TAP-14 input and report diagnostics"},
+		Name: "validate",
+		Description: command.Description{
+			Short: "Validate TAP-14 input and report diagnostics",
+			Long:  "Exits 0 when the stream passes, 1 when tests failed, 2 when the stream is invalid (including a missing or mismatched plan), and 3 when the producer bailed out.",
+		},
 		Params: []command.Param{
 			{Name: "input", Type: command.String, Description: "TAP-14 text to validate (if omitted in CLI mode, reads from stdin)", Required: false},
-			{Name: "format", Type: command.String, Description: "Output format: text, json, or tap (default: text)", Required: false},
-			{Name: "strict", Type: command.Bool, Description: "Fail-fast mode: exit with error if validation fails", Required: false},
+			{Name: "format", Type: command.String, Description: "Output format: text, json, tap, ndjson, or sarif (default: text)", Required: false},
+			{Name: "file", Type: command.String, Description: "Read TAP-14 from this file instead of input or stdin", Required: false},
+			{Name: "sarif-tests", Type: command.Bool, Description: "With --format sarif, also report failed test points that carry file and line YAML", Required: false},
+			{Name: "sarif-uri", Type: command.String, Description: "With --format sarif, the URI of the TAP artifact results point into (default: the --file path, else stdin)", Required: false},
+			{Name: "strict", Type: command.Bool, Description: "Fail-fast mode: return an error result unless the harness verdict is passed", Required: false},
+			{Name: "follow", Type: command.Bool, Description: "Print diagnostics and a running tally as lines arrive (CLI only, text format)", Required: false},
+			{Name: "tee", Type: command.Bool, Description: "Copy input to stdout unchanged and report diagnostics to stderr (CLI only, text format)", Required: false},
+			{Name: "report", Type: command.String, Description: "With --follow or --tee, write the diagnostics report to this file", Required: false},
 		},
-		Run: handleValidate,
+		Run:    handleValidate,
+		RunCLI: handleValidateCLI,
 	})
 
 	app.AddCommand(&command.Command{
@@ -95,61 +119,526 @@ func registerCommands() *command.App {
 		Description: command.Description{Short: "Run go test and convert output to TAP-14"},
 		Params: []command.Param{
 			{Name: "verbose", Type: command.Bool, Description: "Pass -v to go test and include output for passing tests", Required: false},
+			{Name: "stream", Type: command.Bool, Description: "Write each top-level test as soon as it finishes instead of when its package finishes", Required: false},
+			{Name: "bench-thresholds", Type: command.String, Description: "File of benchmark limits, one 'PATTERN UNIT OP LIMIT' per line, e.g. 'BenchmarkParse ns/op <= 5000'", Required: false},
+			{Name: "cover", Type: command.Bool, Description: "Pass -cover to go test and add each package's coverage to its YAML", Required: false},
+			{Name: "cover-min", Type: command.String, Description: "Minimum coverage percentage for every package, checked by extra test points (implies --cover)", Required: false},
+			{Name: "cover-thresholds", Type: command.String, Description: "File of per-package minimums, one 'PATTERN MIN' per line, e.g. 'example.com/foo/... 80' (implies --cover)", Required: false},
+			{Name: "from-json", Type: command.String, Description: "Convert saved go test -json output from FILE, or - for stdin, instead of running go test", Required: false},
+			{Name: "from-text", Type: command.String, Description: "Convert plain go test -v output, or a test binary's -test.v output, from FILE, or - for stdin, instead of running go test", Required: false},
+			{Name: "package", Type: command.String, Description: "Package name for --from-text output without go test's summary lines, e.g. a test binary's; defaults to the file name", Required: false},
+			{Name: "save-json", Type: command.String, Description: "Also write the raw go test -json output to FILE", Required: false},
 		},
 		RunCLI: handleGoTest,
 	})
 
+	app.AddCommand(&command.Command{
+		Name:        "cargo-test",
+		Description: command.Description{Short: "Run cargo test and convert output to TAP-14"},
+		Params: []command.Param{
+			{Name: "verbose", Type: command.Bool, Description: "Pass --show-output to the test binaries and include output for passing tests", Required: false},
+			{Name: "json", Type: command.Bool, Description: "Ask libtest for its JSON format (requires a nightly toolchain)", Required: false},
+		},
+		RunCLI: handleCargoTest,
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "pytest",
+		Description: command.Description{Short: "Run pytest (or read a report log) and convert results to TAP-14"},
+		Params: []command.Param{
+			{Name: "verbose", Type: command.Bool, Description: "Include captured output for passing tests", Required: false},
+			{Name: "from-report-log", Type: command.String, Description: "Convert an existing pytest-reportlog JSONL file instead of running pytest", Required: false},
+		},
+		RunCLI: handlePytest,
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "from-junit",
+		Description: command.Description{Short: "Convert JUnit/xUnit XML reports to TAP-14"},
+		Params: []command.Param{
+			{Name: "system-out", Type: command.Bool, Description: "Attach captured system-out and system-err to test points", Required: false},
+		},
+		RunCLI: handleFromJUnit,
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "to-junit",
+		Description: command.Description{Short: "Convert a TAP-14 stream on stdin to JUnit XML"},
+		Params: []command.Param{
+			{Name: "name", Type: command.String, Description: "Name of the testsuites element and of the suite holding top-level test points (default: tap)", Required: false},
+		},
+		RunCLI: handleToJUnit,
+	})
+
+	app.AddCommand(&command.Command{
+		Name: "github",
+		Description: command.Description{
+			Short: "Print GitHub Actions annotations for a TAP-14 stream",
+			Long:  "Reads TAP from FILE or stdin and prints ::error and ::warning workflow commands for failing tests and protocol problems. Exits with the same codes as validate.",
+		},
+		Params: []command.Param{
+			{Name: "summary", Type: command.String, Description: "Append a Markdown job summary to this file, e.g. \"$GITHUB_STEP_SUMMARY\"", Required: false},
+		},
+		RunCLI: handleGitHub,
+	})
+
+	app.AddCommand(&command.Command{
+		Name: "pretty",
+		Description: command.Description{
+			Short: "Render a TAP-14 stream for people as it arrives",
+			Long:  "Reads TAP from FILE or stdin, e.g. tap-dancer go-test ./... | tap-dancer pretty. Uses color and a live tally when stdout is a terminal and neither --no-color nor NO_COLOR is set. Exits with the same codes as validate.",
+		},
+		Params: []command.Param{
+			{Name: "dots", Type: command.Bool, Description: "Print one character per test instead of one line per test", Required: false},
+			{Name: "no-color", Type: command.Bool, Description: "Never use color", Required: false},
+		},
+		RunCLI: handlePretty,
+	})
+
+	app.AddCommand(&command.Command{
+		Name: "teamcity",
+		Description: command.Description{
+			Short: "Print TeamCity service messages for a TAP-14 stream",
+			Long:  "Reads TAP from FILE or stdin and prints service messages as events arrive, e.g. tap-dancer go-test ./... | tap-dancer teamcity. Exits with the same codes as validate.",
+		},
+		RunCLI: handleTeamCity,
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "summary",
+		Description: command.Description{Short: "Render a compact Markdown summary of a TAP-14 stream"},
+		Params: []command.Param{
+			{Name: "markdown", Type: command.Bool, Description: "Write a Markdown summary (currently the only format)", Required: false},
+			{Name: "max-bytes", Type: command.Int, Description: "Truncate the summary to this many bytes, e.g. 65000 for a PR comment (default: no limit)", Required: false},
+		},
+		RunCLI: handleSummary,
+	})
+
+	app.AddCommand(&command.Command{
+		Name:        "report",
+		Description: command.Description{Short: "Render TAP-14 streams as a self-contained HTML report"},
+		Params: []command.Param{
+			{Name: "html", Type: command.Bool, Description: "Write an HTML report (currently the only format)", Required: false},
+			{Name: "output", Type: command.String, Description: "Write the report to this file instead of stdout", Required: false},
+			{Name: "title", Type: command.String, Description: "Report title (default: TAP report)", Required: false},
+		},
+		RunCLI: handleReport,
+	})
+
+	app.AddCommand(&command.Command{
+		Name: "run",
+		Description: command.Description{
+			Short: "Run TAP-producing scripts in parallel and combine their output",
+			Long:  "Each script becomes a subtest, reported in argument order once it exits; its output is buffered until then, so a long script shows nothing while it runs. A script that times out or cannot be run is reported as not ok with its partial output. Exits with the same codes as validate.",
+		},
+		Params: []command.Param{
+			{Name: "jobs", Type: command.Int, Description: "Number of scripts to run at once (default: 1)", Required: false},
+			{Name: "timeout", Type: command.String, Description: "Per-script timeout as a Go duration, e.g. 30s (default: none)", Required: false},
+		},
+		RunCLI: handleRun,
+	})
+
 	return app
 }
 
 func handleGoTest(ctx context.Context, args json.RawMessage) error {
 	var params struct {
 		Verbose bool `json:"verbose"`
+		Stream  bool `json:"stream"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	// Build go test command args: everything after "go-test" in os.Args
+	// Build go test command args: everything after "go-test" in os.Args,
+	// skipping the flags we handle (-v/--verbose, --stream, ...)
+	rest, values := commandArgs("go-test", []string{"verbose", "stream", "cover"},
+		[]string{"bench-thresholds", "cover-min", "cover-thresholds", "from-json", "from-text", "package", "save-json"})
+	verbose := params.Verbose || slices.Contains(rest, "-v")
+
 	goTestArgs := []string{"test", "-json"}
-	if params.Verbose {
+	if verbose {
 		goTestArgs = append(goTestArgs, "-v")
 	}
 
-	// Find remaining args from os.Args after "go-test"
+	var coverThresholds []tap.CoverageThreshold
+	if min := values["cover-min"]; min != "" {
+		pct, err := tap.ParseCoverageMinimum(min)
+		if err != nil {
+			return fmt.Errorf("invalid --cover-min: %w", err)
+		}
+		coverThresholds = append(coverThresholds, tap.CoverageThreshold{Pattern: "...", Min: pct})
+	}
+	if path := values["cover-thresholds"]; path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening coverage thresholds: %w", err)
+		}
+		thresholds, err := tap.ParseCoverageThresholds(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		coverThresholds = append(coverThresholds, thresholds...)
+	}
+	if values["cover"] != "" || len(coverThresholds) > 0 {
+		goTestArgs = append(goTestArgs, "-cover")
+	}
+
+	var coverProfile string
+	for i, a := range rest {
+		if a == "-v" {
+			continue
+		}
+		goTestArgs = append(goTestArgs, a)
+
+		// Read the profile go test writes for the coverage thresholds.
+		key, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if strings.HasPrefix(a, "-") && key == "coverprofile" {
+			if !hasValue && i+1 < len(rest) {
+				value = rest[i+1]
+			}
+			coverProfile = value
+		}
+	}
+
+	var thresholds []tap.BenchThreshold
+	if path := values["bench-thresholds"]; path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening benchmark thresholds: %w", err)
+		}
+		thresholds, err = tap.ParseBenchThresholds(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	opts := tap.GoTestOptions{
+		Verbose:            verbose,
+		Stream:             params.Stream,
+		Thresholds:         thresholds,
+		CoverageThresholds: coverThresholds,
+		CoverProfile:       coverProfile,
+		ResolveFile:        tap.GoListResolver(""),
+	}
+	if values["from-json"] != "" || values["from-text"] != "" {
+		// Saved output may come from another machine or revision than
+		// the checkout here, so files are placed by import path only.
+		cwd, _ := os.Getwd()
+		opts.ResolveFile = tap.ImportPathResolver(tap.ModulePath(cwd))
+	}
+
+	var save io.Writer
+	if path := values["save-json"]; path != "" {
+		if values["from-text"] != "" {
+			return fmt.Errorf("--save-json needs go test -json output, not --from-text")
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", path, err)
+		}
+		defer f.Close()
+		save = f
+	}
+
+	var exitCode int
+	switch {
+	case values["from-text"] != "":
+		path := values["from-text"]
+		input, err := openInput(path)
+		if err != nil {
+			return fmt.Errorf("opening go test output: %w", err)
+		}
+		defer input.Close()
+		pkg := values["package"]
+		if pkg == "" {
+			pkg = "stdin"
+			if path != "-" {
+				pkg = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+		}
+		exitCode = tap.ConvertGoTestText(input, os.Stdout, pkg, opts)
+	case values["from-json"] != "":
+		input, err := openInput(values["from-json"])
+		if err != nil {
+			return fmt.Errorf("opening go test output: %w", err)
+		}
+		defer input.Close()
+		var r io.Reader = input
+		if save != nil {
+			r = io.TeeReader(r, save)
+		}
+		exitCode = tap.ConvertGoTestWithOptions(r, os.Stdout, opts)
+	default:
+		cmd := exec.CommandContext(ctx, "go", goTestArgs...)
+		cmd.Stderr = os.Stderr
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("creating stdout pipe: %w", err)
+		}
+
+		if err := cmd.Start(); err != nil {
+			// Bail out if go test can't start
+			tw := tap.NewWriter(os.Stdout)
+			tw.BailOut(fmt.Sprintf("failed to start go test: %v", err))
+			return err
+		}
+
+		var r io.Reader = stdout
+		if save != nil {
+			r = io.TeeReader(r, save)
+		}
+		exitCode = tap.ConvertGoTestWithOptions(r, os.Stdout, opts)
+
+		// Wait for command to finish (ignore error — we use our own exit code)
+		cmd.Wait()
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+
+	return nil
+}
+
+// openInput opens path for reading, or stdin for "-".
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// handleValidateCLI handles the CLI-only streaming modes of validate and
+// otherwise defers to handleValidate, printing its result.
+func handleValidateCLI(ctx context.Context, args json.RawMessage) error {
+	var params struct {
+		Input  string `json:"input"`
+		File   string `json:"file"`
+		Format string `json:"format"`
+		Follow bool   `json:"follow"`
+		Tee    bool   `json:"tee"`
+		Report string `json:"report"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.Format == "ndjson" && !params.Follow && !params.Tee {
+		// Stream events as they are read rather than after the whole
+		// input has been consumed.
+		input, closeInput, err := validateInput(params.File, params.Input)
+		if err != nil {
+			return err
+		}
+		defer closeInput()
+		reader := tap.NewReader(input)
+		if err := reader.WriteNDJSON(os.Stdout); err != nil {
+			return err
+		}
+		exitVerdict(reader.Verdict())
+		return nil
+	}
+
+	if !params.Follow && !params.Tee {
+		result, verdict, err := validate(args)
+		if err != nil {
+			return err
+		}
+		printResult(result)
+		exitVerdict(verdict)
+		return nil
+	}
+
+	if params.Format != "" && params.Format != "text" {
+		return fmt.Errorf("--follow and --tee only support text format")
+	}
+
+	input, closeInput, err := validateInput(params.File, params.Input)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	// In tee mode stdout carries the stream itself, so the report goes to
+	// stderr unless a report file is given.
+	var report io.Writer = os.Stdout
+	if params.Tee {
+		input = io.TeeReader(input, os.Stdout)
+		report = os.Stderr
+	}
+	if params.Report != "" {
+		f, err := os.Create(params.Report)
+		if err != nil {
+			return fmt.Errorf("creating report file: %w", err)
+		}
+		defer f.Close()
+		report = f
+	}
+
+	reader := tap.NewReader(input)
+	if params.Follow {
+		// An interrupt ends the input where it stopped, so the final
+		// summary is still printed for a producer that never finishes.
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		if _, err := reader.FollowContext(ctx, report); err != nil {
+			return err
+		}
+	} else if _, err := reader.WriteTo(report); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+// exitVerdict exits with the verdict's exit code unless it passed:
+// 1 for failing tests, 2 for an invalid stream, 3 for a bail out.
+func exitVerdict(v tap.Verdict) {
+	if code := v.ExitCode(); code != tap.ExitPassed {
+		os.Exit(code)
+	}
+}
+
+func printResult(r *command.Result) {
+	if r == nil {
+		return
+	}
+	if r.JSON != nil {
+		data, _ := json.MarshalIndent(r.JSON, "", "  ")
+		fmt.Println(string(data))
+	} else if r.Text != "" {
+		fmt.Println(r.Text)
+	}
+}
+
+func handleCargoTest(ctx context.Context, args json.RawMessage) error {
+	var params struct {
+		Verbose bool `json:"verbose"`
+		JSON    bool `json:"json"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Keep going after a failing test binary so every binary is reported,
+	// as go test does for packages.
+	cargoArgs := []string{"test", "--no-fail-fast"}
+	var binaryArgs []string
+	if params.Verbose {
+		binaryArgs = append(binaryArgs, "--show-output")
+	}
+	if params.JSON {
+		binaryArgs = append(binaryArgs, "-Z", "unstable-options", "--format", "json")
+	}
+
+	// Find remaining args from os.Args after "cargo-test", splitting cargo's
+	// own args from the test binary args that follow "--"
 	for i, arg := range os.Args {
-		if arg == "go-test" {
-			// Skip flags we handle (-v/--verbose) and collect the rest
+		if arg == "cargo-test" {
 			rest := os.Args[i+1:]
-			for _, a := range rest {
-				if a == "-v" || a == "--verbose" {
+			for j, a := range rest {
+				if a == "--" {
+					binaryArgs = append(binaryArgs, rest[j+1:]...)
+					break
+				}
+				if a == "-v" || a == "--verbose" || a == "--json" {
 					continue
 				}
-				goTestArgs = append(goTestArgs, a)
+				cargoArgs = append(cargoArgs, a)
 			}
 			break
 		}
 	}
+	if len(binaryArgs) > 0 {
+		cargoArgs = append(append(cargoArgs, "--"), binaryArgs...)
+	}
 
-	cmd := exec.CommandContext(ctx, "go", goTestArgs...)
-	cmd.Stderr = os.Stderr
+	// The converter needs cargo's stderr ("Running ..." lines, compiler
+	// errors) interleaved with the test binaries' stdout.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("creating output pipe: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "cargo", cargoArgs...)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		pr.Close()
+		// Bail out if cargo test can't start
+		tw := tap.NewWriter(os.Stdout)
+		tw.BailOut(fmt.Sprintf("failed to start cargo test: %v", err))
+		return err
+	}
+	pw.Close()
 
-	stdout, err := cmd.StdoutPipe()
+	exitCode := tap.ConvertCargoTest(pr, os.Stdout, params.Verbose)
+
+	// Wait for command to finish (ignore error — we use our own exit code)
+	cmd.Wait()
+	pr.Close()
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+
+	return nil
+}
+
+func handlePytest(ctx context.Context, _ json.RawMessage) error {
+	// pytest's own arguments would be assigned to the declared params by
+	// the command framework, so flags are read from os.Args directly.
+	rest, flags := commandArgs("pytest", []string{"verbose"}, []string{"from-report-log"})
+	verbose := flags["verbose"] == "true"
+
+	if path, ok := flags["from-report-log"]; ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening report log: %w", err)
+		}
+		defer f.Close()
+		if exitCode := tap.ConvertPytest(f, os.Stdout, verbose); exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	}
+
+	// pytest-reportlog writes to fd 3 so results stream while pytest's own
+	// terminal report goes to stderr.
+	pytestArgs := append([]string{"--report-log=/dev/fd/3"}, rest...)
+
+	pr, pw, err := os.Pipe()
 	if err != nil {
-		return fmt.Errorf("creating stdout pipe: %w", err)
+		return fmt.Errorf("creating report pipe: %w", err)
 	}
 
+	cmd := exec.CommandContext(ctx, "pytest", pytestArgs...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{pw}
+
 	if err := cmd.Start(); err != nil {
-		// Bail out if go test can't start
+		pw.Close()
+		pr.Close()
+		// Bail out if pytest can't start
 		tw := tap.NewWriter(os.Stdout)
-		tw.BailOut(fmt.Sprintf("failed to start go test: %v", err))
+		tw.BailOut(fmt.Sprintf("failed to start pytest: %v", err))
 		return err
 	}
+	pw.Close()
 
-	exitCode := tap.ConvertGoTest(stdout, os.Stdout, params.Verbose)
+	exitCode := tap.ConvertPytest(pr, os.Stdout, verbose)
 
 	// Wait for command to finish (ignore error — we use our own exit code)
 	cmd.Wait()
+	pr.Close()
 
 	if exitCode != 0 {
 		os.Exit(exitCode)
@@ -158,15 +647,338 @@ func handleGoTest(ctx context.Context, args json.RawMessage) error {
 	return nil
 }
 
-func handleValidate(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+func handleFromJUnit(_ context.Context, _ json.RawMessage) error {
+	paths, flags := commandArgs("from-junit", []string{"system-out"}, nil)
+
+	var reports []io.Reader
+	if len(paths) == 0 {
+		reports = append(reports, os.Stdin)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening report: %w", err)
+		}
+		defer f.Close()
+		reports = append(reports, f)
+	}
+
+	if exitCode := tap.ConvertJUnit(os.Stdout, flags["system-out"] == "true", reports...); exitCode != 0 {
+		os.Exit(exitCode)
+	}
+
+	return nil
+}
+
+func handleToJUnit(_ context.Context, args json.RawMessage) error {
 	var params struct {
-		Input  string `json:"input"`
-		Format string `json:"format"`
-		Strict bool   `json:"strict"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Name == "" {
+		params.Name = "tap"
+	}
+
+	reader := tap.NewReader(os.Stdin)
+	if err := tap.WriteJUnit(reader, os.Stdout, params.Name); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+func handleGitHub(_ context.Context, _ json.RawMessage) error {
+	paths, flags := commandArgs("github", nil, []string{"summary"})
+	if len(paths) > 1 {
+		return fmt.Errorf("expected at most one TAP file")
+	}
+
+	var input io.Reader = os.Stdin
+	var opts tap.GitHubOptions
+	if len(paths) == 1 {
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return fmt.Errorf("opening TAP stream: %w", err)
+		}
+		defer f.Close()
+		input = f
+		opts.Source = paths[0]
+	}
+	if flags["summary"] != "" {
+		f, err := os.OpenFile(flags["summary"], os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("opening summary file: %w", err)
+		}
+		defer f.Close()
+		opts.Summary = f
+	}
+
+	reader := tap.NewReader(input)
+	if err := tap.WriteGitHub(reader, os.Stdout, opts); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+func handlePretty(_ context.Context, _ json.RawMessage) error {
+	paths, flags := commandArgs("pretty", []string{"dots", "no-color"}, nil)
+	if len(paths) > 1 {
+		return fmt.Errorf("expected at most one TAP file")
+	}
+
+	var input io.Reader = os.Stdin
+	if len(paths) == 1 {
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return fmt.Errorf("opening TAP stream: %w", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	terminal := false
+	if fi, err := os.Stdout.Stat(); err == nil {
+		terminal = fi.Mode()&os.ModeCharDevice != 0
+	}
+	color := terminal && flags["no-color"] != "true" && os.Getenv("NO_COLOR") == ""
+	opts := tap.PrettyOptions{
+		Dots:  flags["dots"] == "true",
+		Color: color,
+		Live:  color,
+	}
+
+	reader := tap.NewReader(input)
+	if err := tap.WritePretty(reader, os.Stdout, opts); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+func handleTeamCity(_ context.Context, _ json.RawMessage) error {
+	paths, _ := commandArgs("teamcity", nil, nil)
+	if len(paths) > 1 {
+		return fmt.Errorf("expected at most one TAP file")
+	}
+
+	var input io.Reader = os.Stdin
+	if len(paths) == 1 {
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return fmt.Errorf("opening TAP stream: %w", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	reader := tap.NewReader(input)
+	if err := tap.WriteTeamCity(reader, os.Stdout); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+func handleSummary(_ context.Context, _ json.RawMessage) error {
+	paths, flags := commandArgs("summary", []string{"markdown"}, []string{"max-bytes"})
+	if flags["markdown"] != "true" {
+		return fmt.Errorf("no summary format given (use --markdown)")
+	}
+	if len(paths) > 1 {
+		return fmt.Errorf("expected at most one TAP file")
+	}
+
+	var opts tap.MarkdownOptions
+	if v, ok := flags["max-bytes"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("flag --max-bytes: invalid integer %q", v)
+		}
+		opts.MaxBytes = n
+	}
+
+	var input io.Reader = os.Stdin
+	if len(paths) == 1 {
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return fmt.Errorf("opening TAP stream: %w", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	reader := tap.NewReader(input)
+	if err := tap.WriteMarkdown(reader, os.Stdout, opts); err != nil {
+		return err
+	}
+
+	exitVerdict(reader.Verdict())
+	return nil
+}
+
+func handleReport(_ context.Context, _ json.RawMessage) error {
+	paths, flags := commandArgs("report", []string{"html"}, []string{"output", "title"})
+	if flags["html"] != "true" {
+		return fmt.Errorf("no report format given (use --html)")
+	}
+	title := flags["title"]
+	if title == "" {
+		title = "TAP report"
+	}
+
+	var inputs []tap.ReportInput
+	if len(paths) == 0 {
+		inputs = append(inputs, tap.ReportInput{Name: "stdin", Reader: tap.NewReader(os.Stdin)})
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("opening TAP stream: %w", err)
+		}
+		defer f.Close()
+		inputs = append(inputs, tap.ReportInput{Name: path, Reader: tap.NewReader(f)})
+	}
+
+	var out io.Writer = os.Stdout
+	if flags["output"] != "" {
+		f, err := os.Create(flags["output"])
+		if err != nil {
+			return fmt.Errorf("creating report file: %w", err)
+		}
+		defer f.Close()
+		out = f
 	}
 
+	if err := tap.WriteHTML(out, title, inputs...); err != nil {
+		return err
+	}
+	return nil
+}
+
+func handleRun(ctx context.Context, _ json.RawMessage) error {
+	// Positional script paths would be assigned to the declared params by
+	// the command framework, so run reads its flags from os.Args directly.
+	scripts, flags := commandArgs("run", nil, []string{"jobs", "timeout"})
+	if len(scripts) == 0 {
+		return fmt.Errorf("no scripts given")
+	}
+	for _, s := range scripts {
+		if strings.HasPrefix(s, "--") {
+			return fmt.Errorf("unknown flag: %s", s)
+		}
+	}
+
+	var opts tap.RunOptions
+	if v, ok := flags["jobs"]; ok {
+		jobs, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("flag --jobs: invalid integer %q", v)
+		}
+		opts.Jobs = jobs
+	}
+	if v, ok := flags["timeout"]; ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("flag --timeout: %w", err)
+		}
+		opts.Timeout = timeout
+	}
+	opts.Stderr = os.Stderr
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	exitCode := tap.RunScripts(ctx, scripts, os.Stdout, opts)
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+
+	return nil
+}
+
+// commandArgs returns the arguments following the named subcommand in
+// os.Args with the given flags removed, along with those flags' values.
+// Value flags accept both "--flag value" and "--flag=value"; bool flags are
+// recorded as "true". Any other arguments, including unknown flags, are
+// returned in order.
+func commandArgs(name string, boolFlags, valueFlags []string) ([]string, map[string]string) {
+	var rest []string
+	values := make(map[string]string)
+	for i, arg := range os.Args {
+		if arg != name {
+			continue
+		}
+		after := os.Args[i+1:]
+		for j := 0; j < len(after); j++ {
+			a := after[j]
+			key, value, hasValue := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+			switch {
+			case !strings.HasPrefix(a, "--"):
+				rest = append(rest, a)
+			case slices.Contains(boolFlags, key):
+				values[key] = "true"
+			case slices.Contains(valueFlags, key):
+				if !hasValue && j+1 < len(after) {
+					j++
+					value = after[j]
+				}
+				values[key] = value
+			default:
+				rest = append(rest, a)
+			}
+		}
+		break
+	}
+	return rest, values
+}
+
+func handleValidate(ctx context.Context, args json.RawMessage, _ command.Prompter) (*command.Result, error) {
+	result, _, err := validate(args)
+	if err != nil {
+		return command.TextErrorResult(err.Error()), nil
+	}
+	return result, nil
+}
+
+type validateParams struct {
+	Input      string `json:"input"`
+	File       string `json:"file"`
+	Format     string `json:"format"`
+	Strict     bool   `json:"strict"`
+	SARIFTests bool   `json:"sarif-tests"`
+	SARIFURI   string `json:"sarif-uri"`
+}
+
+// validateInput opens the TAP stream validate reads: the file if given,
+// else the input text, else stdin.
+func validateInput(file, text string) (io.Reader, func(), error) {
+	switch {
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening input: %w", err)
+		}
+		return f, func() { f.Close() }, nil
+	case text != "":
+		return strings.NewReader(text), func() {}, nil
+	default:
+		return os.Stdin, func() {}, nil
+	}
+}
+
+// validate runs the validator over the input param (or stdin) and returns
+// the formatted result along with the harness verdict for exit codes.
+func validate(args json.RawMessage) (*command.Result, tap.Verdict, error) {
+	var params validateParams
 	if err := json.Unmarshal(args, &params); err != nil {
-		return command.TextErrorResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		return nil, tap.Verdict{}, fmt.Errorf("invalid arguments: %v", err)
 	}
 
 	// Default format
@@ -176,33 +988,57 @@ func handleValidate(ctx context.Context, args json.RawMessage, _ command.Prompte
 
 	// Validate format
 	switch params.Format {
-	case "text", "json", "tap":
+	case "text", "json", "tap", "ndjson", "sarif":
 		// valid
 	default:
-		return command.TextErrorResult(fmt.Sprintf("invalid format: %s (must be text, json, or tap)", params.Format)), nil
+		return nil, tap.Verdict{}, fmt.Errorf("invalid format: %s (must be text, json, tap, ndjson, or sarif)", params.Format)
 	}
 
-	// Get input (from param or stdin)
-	var input io.Reader
-	if params.Input != "" {
-		input = strings.NewReader(params.Input)
-	} else {
-		input = os.Stdin
+	// Get input (from file, param or stdin)
+	input, closeInput, err := validateInput(params.File, params.Input)
+	if err != nil {
+		return nil, tap.Verdict{}, err
 	}
+	defer closeInput()
 
 	// Parse and validate
 	reader := tap.NewReader(input)
+
+	if params.Format == "ndjson" || params.Format == "sarif" {
+		var sb strings.Builder
+		var err error
+		if params.Format == "ndjson" {
+			err = reader.WriteNDJSON(&sb)
+		} else {
+			uri := params.SARIFURI
+			if uri == "" {
+				uri = params.File
+			}
+			err = tap.WriteSARIF(reader, &sb, tap.SARIFOptions{ArtifactURI: uri, Tests: params.SARIFTests})
+		}
+		if err != nil {
+			return nil, tap.Verdict{}, err
+		}
+		verdict := reader.Verdict()
+		if params.Strict && !verdict.Passed {
+			return command.TextErrorResult(sb.String()), verdict, nil
+		}
+		return command.TextResult(sb.String()), verdict, nil
+	}
+
 	diags := reader.Diagnostics()
 	summary := reader.Summary()
+	verdict := reader.Verdict()
 
 	// Format output
 	switch params.Format {
 	case "json":
 		result := map[string]interface{}{
 			"summary":     summary,
+			"verdict":     verdict,
 			"diagnostics": diags,
 		}
-		return command.JSONResult(result), nil
+		return command.JSONResult(result), verdict, nil
 
 	case "tap":
 		// Output validation results as TAP
@@ -237,10 +1073,10 @@ func handleValidate(ctx context.Context, args json.RawMessage, _ command.Prompte
 
 		tw.Plan()
 
-		if params.Strict && !summary.Valid {
-			return command.TextErrorResult(sb.String()), nil
+		if params.Strict && !verdict.Passed {
+			return command.TextErrorResult(sb.String()), verdict, nil
 		}
-		return command.TextResult(sb.String()), nil
+		return command.TextResult(sb.String()), verdict, nil
 
 	default: // text
 		var sb strings.Builder
@@ -255,10 +1091,22 @@ func handleValidate(ctx context.Context, args json.RawMessage, _ command.Prompte
 		}
 		fmt.Fprintf(&sb, "\n%s: %d tests (%d passed, %d failed, %d skipped, %d todo)\n",
 			status, summary.TotalTests, summary.Passed, summary.Failed, summary.Skipped, summary.Todo)
+		fmt.Fprintf(&sb, "verdict: %s\n", formatVerdict(verdict))
 
-		if params.Strict && !summary.Valid {
-			return command.TextErrorResult(sb.String()), nil
+		if params.Strict && !verdict.Passed {
+			return command.TextErrorResult(sb.String()), verdict, nil
 		}
-		return command.TextResult(sb.String()), nil
+		return command.TextResult(sb.String()), verdict, nil
+	}
+}
+
+func formatVerdict(v tap.Verdict) string {
+	if v.Passed {
+		return "passed"
+	}
+	reasons := make([]string, len(v.Reasons))
+	for i, r := range v.Reasons {
+		reasons[i] = r.String()
 	}
+	return "failed (" + strings.Join(reasons, ", ") + ")"
 }
@@ -0,0 +1,204 @@
+package tap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coverage: 75.0% of statements
+// coverage: 75.0% of statements in ./...
+var coverageRe = regexp.MustCompile(`coverage: (\d+(?:\.\d+)?)% of statements`)
+
+// parseCoverage returns the last statement coverage percentage reported in
+// a package's output.
+func parseCoverage(output string) (float64, bool) {
+	matches := coverageRe.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	pct, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return pct, true
+}
+
+// CoverageThreshold sets the minimum statement coverage, in percent, of the
+// packages matching Pattern. Patterns are import paths as given to go test:
+// "example.com/foo", "example.com/foo/..." for foo and the packages under
+// it, or "..." for every package.
+type CoverageThreshold struct {
+	Pattern string
+	Min     float64
+}
+
+func (t CoverageThreshold) matches(pkg string) bool {
+	if t.Pattern == "..." {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(t.Pattern, "/..."); ok {
+		return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+	}
+	return pkg == t.Pattern
+}
+
+// coverageMinimum returns the minimum that applies to pkg: that of the last
+// matching threshold, so more specific lines can follow a global one.
+func coverageMinimum(pkg string, thresholds []CoverageThreshold) (float64, bool) {
+	var min float64
+	found := false
+	for _, t := range thresholds {
+		if t.matches(pkg) {
+			min, found = t.Min, true
+		}
+	}
+	return min, found
+}
+
+// ParseCoverageThresholds reads coverage thresholds, one per line, as
+//
+//	PATTERN MIN
+//
+// for example "... 60" or "example.com/foo/internal/... 85%". Blank lines
+// and lines starting with # are ignored.
+func ParseCoverageThresholds(r io.Reader) ([]CoverageThreshold, error) {
+	var thresholds []CoverageThreshold
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected PATTERN MIN", lineNum)
+		}
+		min, err := ParseCoverageMinimum(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		thresholds = append(thresholds, CoverageThreshold{Pattern: fields[0], Min: min})
+	}
+	return thresholds, scanner.Err()
+}
+
+// ParseCoverageMinimum parses a coverage minimum such as "80" or "80%",
+// which must lie between 0 and 100.
+func ParseCoverageMinimum(s string) (float64, error) {
+	min, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || min < 0 || min > 100 {
+		return 0, fmt.Errorf("bad minimum %q", s)
+	}
+	return min, nil
+}
+
+// ParseCoverProfile reads a coverage profile, as written by go test
+// -coverprofile, and returns the statement coverage of each package in it.
+// Blocks listed more than once, as in profiles merged from several runs,
+// count as covered if any run covered them.
+func ParseCoverProfile(r io.Reader) (map[string]float64, error) {
+	p := newCoverProfile()
+	if err := p.parse(r); err != nil {
+		return nil, err
+	}
+	return p.coverage(), nil
+}
+
+// coverProfile accumulates the blocks of a coverage profile, keeping
+// per-package statement totals as it goes, so that a profile still being
+// written can be parsed piece by piece as it grows.
+type coverProfile struct {
+	blocks         map[string]*coverBlock
+	total, covered map[string]int
+	lineNum        int
+}
+
+type coverBlock struct {
+	pkg     string
+	stmts   int
+	covered bool
+}
+
+func newCoverProfile() *coverProfile {
+	return &coverProfile{
+		blocks:  make(map[string]*coverBlock),
+		total:   make(map[string]int),
+		covered: make(map[string]int),
+	}
+}
+
+// parse adds the blocks in r to the profile.
+func (p *coverProfile) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// example.com/foo/foo.go:3.14,5.2 1 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("line %d: expected FILE:RANGE STATEMENTS COUNT", p.lineNum)
+		}
+		i := strings.LastIndex(fields[0], ":")
+		if i < 0 {
+			return fmt.Errorf("line %d: missing block range", p.lineNum)
+		}
+		stmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %d: bad statement count %q", p.lineNum, fields[1])
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("line %d: bad count %q", p.lineNum, fields[2])
+		}
+
+		b := p.blocks[fields[0]]
+		if b == nil {
+			b = &coverBlock{pkg: path.Dir(fields[0][:i]), stmts: stmts}
+			p.blocks[fields[0]] = b
+			p.total[b.pkg] += stmts
+		}
+		if !b.covered && count > 0 {
+			b.covered = true
+			p.covered[b.pkg] += b.stmts
+		}
+	}
+	return scanner.Err()
+}
+
+// percent returns a package's statement coverage, if the profile has any
+// statements for it.
+func (p *coverProfile) percent(pkg string) (float64, bool) {
+	n := p.total[pkg]
+	if n == 0 {
+		return 0, false
+	}
+	return 100 * float64(p.covered[pkg]) / float64(n), true
+}
+
+// coverage returns the statement coverage of each package in the profile.
+func (p *coverProfile) coverage() map[string]float64 {
+	coverage := make(map[string]float64)
+	for pkg := range p.total {
+		if pct, ok := p.percent(pkg); ok {
+			coverage[pkg] = pct
+		}
+	}
+	return coverage
+}
+
+// formatCoverage formats a coverage percentage as go test does.
+func formatCoverage(pct float64) string {
+	return strconv.FormatFloat(pct, 'f', 1, 64)
+}
@@ -0,0 +1,102 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCoverage(t *testing.T) {
+	tests := []struct {
+		output string
+		want   float64
+		ok     bool
+	}{
+		{"PASS\ncoverage: 75.0% of statements\nok  \texample.com/foo\t0.004s\tcoverage: 75.0% of statements\n", 75, true},
+		{"coverage: 12.5% of statements in ./...\n", 12.5, true},
+		{"coverage: [no statements]\n", 0, false},
+		{"PASS\n", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCoverage(tt.output)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseCoverage(%q) = %v, %v; want %v, %v", tt.output, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseCoverageThresholds(t *testing.T) {
+	input := `# global minimum first, overridden below
+... 60
+example.com/foo/internal/... 85%
+`
+	thresholds, err := ParseCoverageThresholds(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thresholds) != 2 {
+		t.Fatalf("got %d thresholds, want 2", len(thresholds))
+	}
+
+	for pkg, want := range map[string]float64{
+		"example.com/foo":                60,
+		"example.com/foo/internal":       85,
+		"example.com/foo/internal/parse": 85,
+		"example.com/foo/internalx":      60,
+	} {
+		if got, ok := coverageMinimum(pkg, thresholds); !ok || got != want {
+			t.Errorf("coverageMinimum(%q) = %v, %v; want %v", pkg, got, ok, want)
+		}
+	}
+
+	if _, ok := coverageMinimum("example.com/bar", thresholds[1:]); ok {
+		t.Error("expected no minimum for a package no pattern matches")
+	}
+}
+
+func TestParseCoverageThresholdsErrors(t *testing.T) {
+	for _, input := range []string{"... \n", "... lots\n", "... 120\n", "a b c\n"} {
+		if _, err := ParseCoverageThresholds(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestParseCoverageMinimum(t *testing.T) {
+	for input, want := range map[string]float64{"80": 80, "72.5%": 72.5, "0": 0, "100%": 100} {
+		got, err := ParseCoverageMinimum(input)
+		if err != nil || got != want {
+			t.Errorf("ParseCoverageMinimum(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "lots", "-5", "120", "100.5%"} {
+		if _, err := ParseCoverageMinimum(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestParseCoverProfile(t *testing.T) {
+	// Merged from two runs: the block at foo.go:7 is covered by the second.
+	profile := `mode: set
+example.com/foo/foo.go:3.20,5.2 2 1
+example.com/foo/foo.go:7.20,9.2 1 0
+example.com/foo/bar/bar.go:3.14,4.2 4 0
+mode: set
+example.com/foo/foo.go:3.20,5.2 2 0
+example.com/foo/foo.go:7.20,9.2 1 1
+`
+	coverage, err := ParseCoverProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := coverage["example.com/foo"]; got != 100 {
+		t.Errorf("example.com/foo coverage = %v, want 100", got)
+	}
+	if got := coverage["example.com/foo/bar"]; got != 0 {
+		t.Errorf("example.com/foo/bar coverage = %v, want 0", got)
+	}
+
+	if _, err := ParseCoverProfile(strings.NewReader("example.com/foo/foo.go:3.20,5.2 two 1\n")); err == nil {
+		t.Error("expected error for a bad statement count")
+	}
+}
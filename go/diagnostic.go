@@ -1,5 +1,7 @@
 package tap
 
+import "fmt"
+
 // Severity indicates the severity of a validation diagnostic.
 type Severity int
 
@@ -19,6 +21,24 @@ func (s Severity) String() string {
 	}
 }
 
+// MarshalText encodes a Severity as its stable string name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a Severity from its string name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "error":
+		*s = SeverityError
+	case "warning":
+		*s = SeverityWarning
+	default:
+		return fmt.Errorf("unknown severity: %q", text)
+	}
+	return nil
+}
+
 // Diagnostic represents a single validation problem found in TAP input.
 type Diagnostic struct {
 	Line     int      `json:"line"`
@@ -47,6 +67,26 @@ func (d Directive) String() string {
 	}
 }
 
+// MarshalText encodes a Directive as "SKIP", "TODO", or "" for none.
+func (d Directive) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a Directive from its string name.
+func (d *Directive) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "":
+		*d = DirectiveNone
+	case "SKIP":
+		*d = DirectiveSkip
+	case "TODO":
+		*d = DirectiveTodo
+	default:
+		return fmt.Errorf("unknown directive: %q", text)
+	}
+	return nil
+}
+
 // EventType classifies a parsed TAP line.
 type EventType int
 
@@ -63,6 +103,42 @@ const (
 	EventUnknown
 )
 
+var eventTypeNames = [...]string{
+	EventVersion:        "version",
+	EventPlan:           "plan",
+	EventTestPoint:      "test_point",
+	EventYAMLDiagnostic: "yaml",
+	EventComment:        "comment",
+	EventBailOut:        "bail_out",
+	EventPragma:         "pragma",
+	EventSubtestStart:   "subtest_start",
+	EventSubtestEnd:     "subtest_end",
+	EventUnknown:        "unknown",
+}
+
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return "unknown"
+}
+
+// MarshalText encodes an EventType as its stable string name.
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes an EventType from its string name.
+func (t *EventType) UnmarshalText(text []byte) error {
+	for i, name := range eventTypeNames {
+		if name == string(text) {
+			*t = EventType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event type: %q", text)
+}
+
 // TestPointResult holds parsed data from a test point line.
 type TestPointResult struct {
 	Number      int       `json:"number"`
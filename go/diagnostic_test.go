@@ -32,3 +32,34 @@ func TestDirectiveString(t *testing.T) {
 		}
 	}
 }
+
+func TestEventTypeTextRoundTrip(t *testing.T) {
+	for et := EventVersion; et <= EventUnknown; et++ {
+		text, err := et.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%d): %v", et, err)
+		}
+		var got EventType
+		if err := got.UnmarshalText(text); err != nil || got != et {
+			t.Errorf("EventType %q round-tripped to %d (err %v), want %d", text, got, err, et)
+		}
+	}
+	if EventTestPoint.String() != "test_point" {
+		t.Errorf("EventTestPoint.String() = %q, want %q", EventTestPoint.String(), "test_point")
+	}
+}
+
+func TestSeverityAndDirectiveUnmarshalText(t *testing.T) {
+	var s Severity
+	if err := s.UnmarshalText([]byte("warning")); err != nil || s != SeverityWarning {
+		t.Errorf("UnmarshalText(warning) = %v, %v", s, err)
+	}
+	if err := s.UnmarshalText([]byte("fatal")); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+
+	var d Directive
+	if err := d.UnmarshalText([]byte("TODO")); err != nil || d != DirectiveTodo {
+		t.Errorf("UnmarshalText(TODO) = %v, %v", d, err)
+	}
+}
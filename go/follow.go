@@ -0,0 +1,115 @@
+package tap
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Follow consumes the stream line by line, writing each diagnostic to w as
+// soon as it is found and a running tally after every top-level test point
+// or bail out. At end of input it writes the same final status line as
+// WriteTo and returns the summary. Unlike WriteTo, nothing is buffered, so
+// Follow is suitable for long-running producers.
+func (r *Reader) Follow(w io.Writer) (Summary, error) {
+	return r.follow(context.Background(), w)
+}
+
+// FollowContext is Follow for a producer that may never finish: once ctx
+// is done the input ends where it stopped, so the final status line
+// reflects what was read so far. A read still waiting on the producer is
+// cut short only if FollowContext is called before anything is read; the
+// wait is then abandoned, and ended outright for sources with read
+// deadlines such as pipes. Otherwise ctx is checked between lines.
+func (r *Reader) FollowContext(ctx context.Context, w io.Writer) (Summary, error) {
+	if r.lineNum == 0 {
+		input := &contextReader{src: r.src, ctx: ctx}
+		r.scanner = bufio.NewScanner(input)
+		defer input.close()
+	}
+	return r.follow(ctx, w)
+}
+
+func (r *Reader) follow(ctx context.Context, w io.Writer) (Summary, error) {
+	reported := 0
+	for ctx.Err() == nil {
+		ev, nextErr := r.Next()
+
+		for ; reported < len(r.diags); reported++ {
+			if _, err := io.WriteString(w, formatDiagnostic(r.diags[reported])); err != nil {
+				return r.snapshot(), err
+			}
+		}
+
+		if nextErr != nil {
+			break
+		}
+
+		if (ev.Type == EventTestPoint && ev.Depth == 0) || ev.Type == EventBailOut {
+			if _, err := io.WriteString(w, formatTally(r.snapshot())); err != nil {
+				return r.snapshot(), err
+			}
+		}
+	}
+
+	summary := r.Summary()
+	_, err := io.WriteString(w, "\n"+formatSummary(summary))
+	return summary, err
+}
+
+// contextReader reads from src until ctx is done. Each read runs in its
+// own goroutine, into a buffer it owns, so that waiting on it can be cut
+// short; the Reader's own state is only ever touched by its caller.
+type contextReader struct {
+	src  io.Reader
+	ctx  context.Context
+	buf  []byte
+	stop func() bool
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if c.stop == nil {
+		// Unblock the pending read, and so its goroutine, where the
+		// source allows it.
+		if d, ok := c.src.(interface{ SetReadDeadline(time.Time) error }); ok {
+			c.stop = context.AfterFunc(c.ctx, func() { d.SetReadDeadline(time.Now()) })
+		} else {
+			c.stop = func() bool { return false }
+		}
+	}
+	if len(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	done := make(chan readResult, 1)
+	go func(buf []byte) {
+		n, err := c.src.Read(buf)
+		done <- readResult{n, err}
+	}(c.buf[:len(p)])
+	select {
+	case res := <-done:
+		return copy(p, c.buf[:res.n]), res.err
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	}
+}
+
+func (c *contextReader) close() {
+	if c.stop != nil {
+		c.stop()
+	}
+}
+
+func formatTally(s Summary) string {
+	return fmt.Sprintf("# running: %d passed, %d failed, %d skipped, %d todo\n",
+		s.Passed, s.Failed, s.Skipped, s.Todo)
+}
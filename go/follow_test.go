@@ -0,0 +1,122 @@
+package tap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowWritesTallyPerTestPoint(t *testing.T) {
+	input := "TAP version 14\n1..2\nok 1 - a\nnot ok 2 - b\n"
+	r := NewReader(strings.NewReader(input))
+	var buf strings.Builder
+	summary, err := r.Follow(&buf)
+	if err != nil {
+		t.Fatalf("Follow error: %v", err)
+	}
+	if !summary.Valid {
+		t.Error("expected Valid=true")
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "# running: 1 passed, 0 failed, 0 skipped, 0 todo\n") {
+		t.Errorf("expected tally after first test point, got:\n%s", out)
+	}
+	if !strings.Contains(out, "# running: 1 passed, 1 failed, 0 skipped, 0 todo\n") {
+		t.Errorf("expected tally after second test point, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "\nvalid: 2 tests (1 passed, 1 failed, 0 skipped, 0 todo)\n") {
+		t.Errorf("expected final summary line, got:\n%s", out)
+	}
+}
+
+func TestFollowReportsDiagnosticsBeforeEOF(t *testing.T) {
+	pr, pw := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan Summary)
+	go func() {
+		summary, _ := NewReader(pr).Follow(outW)
+		outW.Close()
+		done <- summary
+	}()
+
+	lines := bufio.NewScanner(outR)
+
+	io.WriteString(pw, "TAP version 14\nok 2 - a\n")
+	if !lines.Scan() {
+		t.Fatal("expected diagnostic line while stream is still open")
+	}
+	if !strings.Contains(lines.Text(), "test-number-sequence") {
+		t.Errorf("expected test-number-sequence diagnostic, got %q", lines.Text())
+	}
+	if !lines.Scan() {
+		t.Fatal("expected tally line")
+	}
+	if !strings.HasPrefix(lines.Text(), "# running:") {
+		t.Errorf("expected tally line, got %q", lines.Text())
+	}
+
+	io.WriteString(pw, "1..2\n")
+	pw.Close()
+	for lines.Scan() {
+	}
+
+	summary := <-done
+	if summary.Valid {
+		t.Error("expected Valid=false")
+	}
+}
+
+func TestFollowContextStopsOnCancel(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+	outR, outW := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan Summary)
+	go func() {
+		summary, _ := NewReader(pr).FollowContext(ctx, outW)
+		outW.Close()
+		done <- summary
+	}()
+
+	lines := bufio.NewScanner(outR)
+	io.WriteString(pw, "TAP version 14\nok 1 - a\n")
+	if !lines.Scan() || !strings.HasPrefix(lines.Text(), "# running:") {
+		t.Fatalf("expected tally line, got %q", lines.Text())
+	}
+
+	// The producer stays open and quiet; cancelling must still end Follow.
+	cancel()
+	var rest []string
+	for lines.Scan() {
+		rest = append(rest, lines.Text())
+	}
+
+	select {
+	case summary := <-done:
+		if summary.Passed != 1 {
+			t.Errorf("expected 1 passed, got %d", summary.Passed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Follow did not return after cancel")
+	}
+	if len(rest) == 0 || !strings.Contains(rest[len(rest)-1], "1 tests (1 passed") {
+		t.Errorf("expected final summary line, got %q", rest)
+	}
+
+	// The abandoned read was ended by a deadline rather than left waiting.
+	if _, err := pr.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected the pipe's read deadline to be set, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package tap
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Failing input written to testdata/fuzz/FuzzReverse/1de061fa29cfbb3d
+var fuzzInputRe = regexp.MustCompile(`^Failing input written to (\S+)$`)
+
+// isFuzzProgress reports whether a trimmed output line is a fuzzing status
+// line, such as "fuzz: elapsed: 3s, execs: 1024 (341/sec), new interesting: 2".
+func isFuzzProgress(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "fuzz: ")
+}
+
+// fuzzReport recognizes the note go test prints after fuzzing finds a
+// failing input: where the input was written and how to run it again. It
+// is fed a test's trimmed output lines in order, so that a "go test -run="
+// line only counts when it follows the "Failing input written to" line.
+type fuzzReport struct {
+	open bool
+}
+
+// match reports whether trimmed is part of the note.
+func (f *fuzzReport) match(trimmed string) bool {
+	switch {
+	case fuzzInputRe.MatchString(trimmed):
+		f.open = true
+		return true
+	case f.open && (trimmed == "To re-run:" || strings.HasPrefix(trimmed, "go test -run=")):
+		return true
+	case trimmed != "":
+		f.open = false
+	}
+	return false
+}
+
+// fuzzProgress returns the fuzzing status lines of a test's output.
+func fuzzProgress(raw string) []string {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		if trimmed := strings.TrimSpace(line); isFuzzProgress(trimmed) {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
+
+// fuzzDiagnostics returns the corpus file and reproducer command of a
+// failing fuzz test: either the input fuzzing just found, from the note in
+// its output, or a corpus entry under testdata/fuzz, run as a subtest of
+// its fuzz target named after the file.
+func fuzzDiagnostics(pkg, name, raw string) map[string]string {
+	diag := make(map[string]string)
+	var report fuzzReport
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !report.match(trimmed) {
+			continue
+		}
+		if m := fuzzInputRe.FindStringSubmatch(trimmed); m != nil {
+			diag["corpus_file"] = m[1]
+		} else if strings.HasPrefix(trimmed, "go test -run=") {
+			diag["reproduce"] = trimmed + " " + pkg
+		}
+	}
+	if len(diag) > 0 {
+		return diag
+	}
+
+	target, entry, ok := strings.Cut(name, "/")
+	if !ok || !strings.HasPrefix(target, "Fuzz") || strings.Contains(entry, "/") || strings.HasPrefix(entry, "seed#") {
+		return diag
+	}
+	diag["corpus_file"] = "testdata/fuzz/" + name
+	diag["reproduce"] = "go test -run=" + name + " " + pkg
+	return diag
+}
@@ -0,0 +1,84 @@
+package tap
+
+import "testing"
+
+func TestFuzzDiagnosticsFromFailingInput(t *testing.T) {
+	output := "    --- FAIL: FuzzReverse (0.00s)\n" +
+		"        fz_test.go:10: bad input \"x000\"\n" +
+		"    \n" +
+		"    Failing input written to testdata/fuzz/FuzzReverse/1de061fa29cfbb3d\n" +
+		"    To re-run:\n" +
+		"    go test -run=FuzzReverse/1de061fa29cfbb3d\n"
+
+	diag := fuzzDiagnostics("example.com/fz", "FuzzReverse", output)
+	if diag["corpus_file"] != "testdata/fuzz/FuzzReverse/1de061fa29cfbb3d" {
+		t.Errorf("corpus_file = %q", diag["corpus_file"])
+	}
+	if diag["reproduce"] != "go test -run=FuzzReverse/1de061fa29cfbb3d example.com/fz" {
+		t.Errorf("reproduce = %q", diag["reproduce"])
+	}
+}
+
+func TestFuzzDiagnosticsFromCorpusEntry(t *testing.T) {
+	diag := fuzzDiagnostics("example.com/fz", "FuzzReverse/1de061fa29cfbb3d", "fz_test.go:10: bad input\n")
+	if diag["corpus_file"] != "testdata/fuzz/FuzzReverse/1de061fa29cfbb3d" {
+		t.Errorf("corpus_file = %q", diag["corpus_file"])
+	}
+	if diag["reproduce"] != "go test -run=FuzzReverse/1de061fa29cfbb3d example.com/fz" {
+		t.Errorf("reproduce = %q", diag["reproduce"])
+	}
+}
+
+func TestFuzzDiagnosticsIgnoresOtherTests(t *testing.T) {
+	for _, name := range []string{
+		"FuzzReverse/seed#0",
+		"FuzzReverse",
+		"TestReverse/case",
+		"FuzzReverse/1de061fa29cfbb3d/inner",
+	} {
+		if diag := fuzzDiagnostics("example.com/fz", name, "fz_test.go:10: bad\n"); len(diag) != 0 {
+			t.Errorf("fuzzDiagnostics(%q) = %v, want none", name, diag)
+		}
+	}
+}
+
+func TestCleanTestOutputDropsFuzzLines(t *testing.T) {
+	output := "=== RUN   FuzzReverse\n" +
+		"fuzz: elapsed: 0s, gathering baseline coverage: 0/2 completed\n" +
+		"fuzz: minimizing 49-byte failing input file\n" +
+		"--- FAIL: FuzzReverse (0.02s)\n" +
+		"    fz_test.go:10: bad input \"x000\"\n" +
+		"    Failing input written to testdata/fuzz/FuzzReverse/1de061fa29cfbb3d\n" +
+		"    To re-run:\n" +
+		"    go test -run=FuzzReverse/1de061fa29cfbb3d\n"
+
+	if got, want := cleanTestOutput(output), `fz_test.go:10: bad input "x000"`; got != want {
+		t.Errorf("cleanTestOutput = %q, want %q", got, want)
+	}
+	if got := fuzzProgress(output); len(got) != 2 || got[1] != "fuzz: minimizing 49-byte failing input file" {
+		t.Errorf("fuzzProgress = %q", got)
+	}
+}
+
+func TestCleanTestOutputKeepsRunLinesOutsideFuzzReport(t *testing.T) {
+	output := "=== RUN   TestHelp\n" +
+		"go test -run=TestHelp ./...\n" +
+		"To re-run:\n" +
+		"--- PASS: TestHelp (0.00s)\n"
+
+	if got, want := cleanTestOutput(output), "go test -run=TestHelp ./...\nTo re-run:"; got != want {
+		t.Errorf("cleanTestOutput = %q, want %q", got, want)
+	}
+	if diag := fuzzDiagnostics("example.com/fz", "TestHelp", output); len(diag) != 0 {
+		t.Errorf("fuzzDiagnostics = %v, want none", diag)
+	}
+}
+
+func TestExtractSkipReasonSkipsFuzzProgress(t *testing.T) {
+	output := "fuzz: elapsed: 0s, gathering baseline coverage: 0/2 completed\n" +
+		"    fz_test.go:8: needs network\n" +
+		"--- SKIP: FuzzFetch (0.00s)\n"
+	if got, want := extractSkipReason(output), "fz_test.go:8: needs network"; got != want {
+		t.Errorf("extractSkipReason = %q, want %q", got, want)
+	}
+}
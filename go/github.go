@@ -0,0 +1,79 @@
+package tap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubOptions configures WriteGitHub.
+type GitHubOptions struct {
+	// Source names the TAP stream in annotations for protocol problems,
+	// which point at a line of the stream itself. If empty, those
+	// annotations carry only the line in their message.
+	Source string
+	// Summary, if non-nil, receives a Markdown job summary, e.g. the file
+	// named by $GITHUB_STEP_SUMMARY.
+	Summary io.Writer
+}
+
+// WriteGitHub reads a TAP stream and writes GitHub Actions workflow
+// commands to w: an ::error for each failing test, located by its file and
+// line YAML keys when present, an ::error for a bail out, and an ::error or
+// ::warning for each protocol diagnostic, located by its line in the
+// stream. Tests are named by their path through subtests, e.g. "pkg/TestA".
+func WriteGitHub(r *Reader, w io.Writer, opts GitHubOptions) error {
+	root := buildTapTree(r, nil)
+
+	var results testResults
+	for _, node := range root.children {
+		results.collect(node, "")
+	}
+
+	for _, f := range results.failures {
+		props := []string{"title=" + escapeGitHubProperty(f.name)}
+		if f.file != "" {
+			props = append(props, "file="+escapeGitHubProperty(f.file))
+			if f.line != "" {
+				props = append(props, "line="+escapeGitHubProperty(f.line))
+			}
+		}
+		if _, err := fmt.Fprintf(w, "::error %s::%s\n", strings.Join(props, ","), escapeGitHubData(f.message)); err != nil {
+			return err
+		}
+	}
+
+	diags := r.Diagnostics()
+	for _, d := range diags {
+		var props []string
+		message := d.Message
+		if opts.Source != "" {
+			props = append(props, "file="+escapeGitHubProperty(opts.Source), fmt.Sprintf("line=%d", d.Line))
+		} else {
+			message = fmt.Sprintf("line %d: %s", d.Line, d.Message)
+		}
+		props = append(props, "title="+escapeGitHubProperty("TAP "+d.Rule))
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", d.Severity, strings.Join(props, ","), escapeGitHubData(message)); err != nil {
+			return err
+		}
+	}
+
+	if opts.Summary == nil {
+		return nil
+	}
+	return writeMarkdownSummary(opts.Summary, &results, r.Verdict(), diags, 0)
+}
+
+// escapeGitHubData escapes a workflow command's message.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
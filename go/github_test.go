@@ -0,0 +1,91 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubFailureAnnotation(t *testing.T) {
+	input := `TAP version 14
+# Subtest: example.com/pkg
+    1..2
+    ok 1 - TestA
+    not ok 2 - TestB
+      ---
+      file: pkg_test.go
+      line: 12
+      message: |
+        pkg_test.go:12: got 1, want 2
+        100% wrong
+      ...
+not ok 1 - example.com/pkg
+1..1
+`
+	var out strings.Builder
+	if err := WriteGitHub(NewReader(strings.NewReader(input)), &out, GitHubOptions{}); err != nil {
+		t.Fatalf("WriteGitHub error: %v", err)
+	}
+
+	want := "::error title=example.com/pkg/TestB,file=pkg_test.go,line=12::pkg_test.go:12: got 1, want 2%0A100%25 wrong\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("expected annotation %q, got:\n%s", want, out.String())
+	}
+	if strings.Count(out.String(), "::error") != 1 {
+		t.Errorf("expected the passing subtest's summary not to be annotated:\n%s", out.String())
+	}
+}
+
+func TestWriteGitHubProtocolDiagnostics(t *testing.T) {
+	input := "TAP version 14\n1..3\nok 1 - a\n"
+
+	var out strings.Builder
+	WriteGitHub(NewReader(strings.NewReader(input)), &out, GitHubOptions{Source: "results.tap"})
+
+	if !strings.Contains(out.String(), "::error file=results.tap,line=3,title=TAP plan-count-mismatch::") {
+		t.Errorf("expected located protocol error, got:\n%s", out.String())
+	}
+}
+
+func TestWriteGitHubFailedSubtestWithoutFailingChildren(t *testing.T) {
+	input := `TAP version 14
+# Subtest: pkg
+    1..1
+    ok 1 - TestA
+not ok 1 - pkg
+  ---
+  message: panic: boom
+  ...
+1..1
+`
+	var out strings.Builder
+	WriteGitHub(NewReader(strings.NewReader(input)), &out, GitHubOptions{})
+
+	if !strings.Contains(out.String(), "::error title=pkg::panic: boom\n") {
+		t.Errorf("expected annotation for failed subtest, got:\n%s", out.String())
+	}
+}
+
+func TestWriteGitHubSummary(t *testing.T) {
+	input := "TAP version 14\n1..3\nok 1 - a\nnot ok 2 - b\nok 3 - c # SKIP later\n"
+
+	var out, summary strings.Builder
+	WriteGitHub(NewReader(strings.NewReader(input)), &out, GitHubOptions{Summary: &summary})
+
+	s := summary.String()
+	if !strings.Contains(s, "failed (tests-failed)") {
+		t.Errorf("expected verdict in summary:\n%s", s)
+	}
+	if !strings.Contains(s, "| 3 | 1 | 1 | 1 | 0 |") {
+		t.Errorf("expected counts table in summary:\n%s", s)
+	}
+	if !strings.Contains(s, "<code>b</code>") {
+		t.Errorf("expected failure listed in summary:\n%s", s)
+	}
+}
+
+func TestEscapeGitHubProperty(t *testing.T) {
+	got := escapeGitHubProperty("a:b,c%d\ne")
+	if want := "a%3Ab%2Cc%25d%0Ae"; got != want {
+		t.Errorf("escapeGitHubProperty = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package tap
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// GoListResolver returns a GoTestOptions.ResolveFile function that reports
+// files relative to the root of the repository holding them, or of their
+// module outside a repository. It asks go list, run in dir, for the
+// directory of each package once. Bare file names, as tests print them,
+// are looked for in that directory; absolute paths, as panics and
+// -fullpath print them, are used as they are. Files it cannot place, such
+// as helpers in another package, are reported as given.
+func GoListResolver(dir string) func(pkg, file string) string {
+	var mu sync.Mutex
+	packages := make(map[string]goListPackage)
+
+	return func(pkg, file string) string {
+		mu.Lock()
+		p, ok := packages[pkg]
+		if !ok {
+			p = goList(dir, pkg)
+			packages[pkg] = p
+		}
+		mu.Unlock()
+
+		path := file
+		if !filepath.IsAbs(path) {
+			if p.dir == "" {
+				return file
+			}
+			path = filepath.Join(p.dir, file)
+			if _, err := os.Stat(path); err != nil {
+				return file
+			}
+		}
+		root := p.root
+		if root == "" {
+			root = repoRoot(filepath.Dir(path))
+		}
+		if root == "" {
+			return file
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return file
+		}
+		return filepath.ToSlash(rel)
+	}
+}
+
+// ImportPathResolver returns a GoTestOptions.ResolveFile function for
+// saved output, which may come from another machine or revision, so that
+// files are placed by import path alone rather than looked up. Files are
+// reported relative to the root of module: bare file names go in their
+// package's directory below it, and absolute paths are cut down to that
+// directory when they lie in it. Files of packages outside module, or
+// that cannot be placed, are reported as given.
+func ImportPathResolver(module string) func(pkg, file string) string {
+	return func(pkg, file string) string {
+		if module == "" {
+			return file
+		}
+		var rel string
+		switch {
+		case pkg == module:
+		case strings.HasPrefix(pkg, module+"/"):
+			rel = strings.TrimPrefix(pkg, module+"/")
+		default:
+			return file
+		}
+
+		slashed := filepath.ToSlash(file)
+		if !path.IsAbs(slashed) && !filepath.IsAbs(file) {
+			// Build errors name files as ./foo_test.go.
+			if name := path.Clean(slashed); !strings.Contains(name, "/") {
+				return path.Join(rel, name)
+			}
+			return file
+		}
+		file = slashed
+		// The module's own root cannot be told apart within an absolute
+		// path, so only files of packages below it are placed.
+		if dir, name := path.Split(file); rel != "" && strings.HasSuffix(dir, "/"+rel+"/") {
+			return path.Join(rel, name)
+		}
+		return file
+	}
+}
+
+// ModulePath returns the module path declared by the nearest go.mod at or
+// above dir, or "" if there is none.
+func ModulePath(dir string) string {
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if mod, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return strings.Trim(strings.TrimSpace(mod), `"`)
+				}
+			}
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+type goListPackage struct {
+	dir  string
+	root string
+}
+
+// goList looks up the directory of the package with import path pkg and
+// the root its files are reported relative to. Both are empty if go list
+// does not know the package, e.g. a test binary's name.
+func goList(dir, pkg string) goListPackage {
+	cmd := exec.Command("go", "list", "-e", "-f", "{{.Dir}}\t{{with .Module}}{{.Dir}}{{end}}", pkg)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return goListPackage{}
+	}
+	pkgDir, modDir, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	if pkgDir == "" {
+		return goListPackage{}
+	}
+	root := repoRoot(pkgDir)
+	if root == "" {
+		root = modDir
+	}
+	return goListPackage{dir: pkgDir, root: root}
+}
+
+// repoRoot returns the nearest directory at or above dir holding a .git
+// entry, or "" if there is none.
+func repoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,77 @@
+package tap
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoListResolver(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".git/HEAD":           "ref: refs/heads/main\n",
+		"mod/go.mod":          "module example.com/m\n\ngo 1.21\n",
+		"mod/p/p.go":          "package p\n",
+		"mod/p/p_test.go":     "package p\n",
+		"mod/p/sub/helper.go": "package sub\n",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolve := GoListResolver(filepath.Join(root, "mod"))
+	for _, tc := range []struct {
+		pkg, file, want string
+	}{
+		{"example.com/m/p", "p_test.go", "mod/p/p_test.go"},
+		{"example.com/m/p", filepath.Join(root, "mod", "p", "sub", "helper.go"), "mod/p/sub/helper.go"},
+		{"example.com/m/p", "helper.go", "helper.go"},
+		{"example.com/m/missing", "x_test.go", "x_test.go"},
+		{"example.com/m/p", "/elsewhere/x.go", "/elsewhere/x.go"},
+	} {
+		if got := resolve(tc.pkg, tc.file); got != tc.want {
+			t.Errorf("resolve(%q, %q) = %q, want %q", tc.pkg, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestImportPathResolver(t *testing.T) {
+	resolve := ImportPathResolver("example.com/m")
+	for _, tc := range []struct{ pkg, file, want string }{
+		{"example.com/m/sub", "sub_test.go", "sub/sub_test.go"},
+		{"example.com/m/sub", "./sub_test.go", "sub/sub_test.go"},
+		{"example.com/m", "m_test.go", "m_test.go"},
+		{"example.com/m/sub", "/elsewhere/m/sub/sub_test.go", "sub/sub_test.go"},
+		{"example.com/m/sub", "/usr/local/go/src/testing/testing.go", "/usr/local/go/src/testing/testing.go"},
+		{"example.com/m", "/elsewhere/m/m_test.go", "/elsewhere/m/m_test.go"},
+		{"example.com/other", "o_test.go", "o_test.go"},
+	} {
+		if got := resolve(tc.pkg, tc.file); got != tc.want {
+			t.Errorf("resolve(%q, %q) = %q, want %q", tc.pkg, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ModulePath(sub); got != "example.com/m" {
+		t.Errorf("ModulePath = %q, want example.com/m", got)
+	}
+}
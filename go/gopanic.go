@@ -0,0 +1,234 @@
+package tap
+
+import (
+	"regexp"
+	"strings"
+)
+
+// goPanic is a panic, or a fatal runtime error, parsed from test output.
+type goPanic struct {
+	value string
+	// timeout is the -timeout that ran out, e.g. "2s", and running lists
+	// the tests that were running at the time.
+	timeout string
+	running []string
+	// goroutine is the header of the goroutine whose frames are kept,
+	// e.g. "8 [running]".
+	goroutine string
+	frames    []stackFrame
+	// goroot is the Go root the trace's standard library frames live
+	// under, e.g. "/usr/local/go", if any of them show it.
+	goroot string
+}
+
+type stackFrame struct {
+	function, file, line string
+}
+
+var (
+	// goroutine 8 [running]:
+	goroutineRe = regexp.MustCompile(`^goroutine (\d+)[^\[]*\[([^\]]*)\]:$`)
+	// 	/tmp/pn/p_test.go:14 +0x28
+	stackFileRe = regexp.MustCompile(`^\t(.+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	// 		TestSlow (2s)
+	runningTestRe = regexp.MustCompile(`^\t\t(\S+) \([^)]*\)$`)
+	recoveredRe   = regexp.MustCompile(` \[recovered[^\]]*\]$`)
+)
+
+// parseGoPanic parses the first panic or fatal error in a test binary's
+// output, along with its stack trace. Of the goroutines in the trace, it
+// keeps the first one running code outside the standard library: for a
+// panic that is the one that panicked, and for a timeout the test that was
+// still running rather than the alarm that fired.
+func parseGoPanic(output string) *goPanic {
+	lines := strings.Split(output, "\n")
+	start := -1
+	var p goPanic
+	for i, line := range lines {
+		if v, ok := strings.CutPrefix(line, "panic: "); ok {
+			p.value = recoveredRe.ReplaceAllString(v, "")
+		} else if v, ok := strings.CutPrefix(line, "fatal error: "); ok {
+			p.value = v
+		} else {
+			continue
+		}
+		start = i
+		break
+	}
+	if start < 0 {
+		return nil
+	}
+	if d, ok := strings.CutPrefix(p.value, "test timed out after "); ok {
+		p.timeout = d
+	}
+
+	type goroutine struct {
+		header string
+		frames []stackFrame
+	}
+	var goroutines []goroutine
+	for _, line := range lines[start+1:] {
+		if m := runningTestRe.FindStringSubmatch(line); m != nil && len(goroutines) == 0 {
+			p.running = append(p.running, m[1])
+			continue
+		}
+		if m := goroutineRe.FindStringSubmatch(line); m != nil {
+			goroutines = append(goroutines, goroutine{header: m[1] + " [" + m[2] + "]"})
+			continue
+		}
+		if len(goroutines) == 0 {
+			continue
+		}
+		g := &goroutines[len(goroutines)-1]
+		if m := stackFileRe.FindStringSubmatch(line); m != nil && len(g.frames) > 0 {
+			last := &g.frames[len(g.frames)-1]
+			if last.file == "" {
+				last.file, last.line = m[1], m[2]
+			}
+			continue
+		}
+		if line != "" && !strings.HasPrefix(line, "\t") {
+			g.frames = append(g.frames, stackFrame{function: stackFunction(line)})
+		}
+	}
+
+	for _, g := range goroutines {
+		for _, f := range g.frames {
+			if root, ok := goRoot(f); ok {
+				p.goroot = root
+			}
+		}
+	}
+	if len(goroutines) > 0 {
+		chosen := goroutines[0]
+		for _, g := range goroutines {
+			if userFrame(g.frames, p.goroot) != nil {
+				chosen = g
+				break
+			}
+		}
+		p.goroutine = chosen.header
+		p.frames = chosen.frames
+	}
+	return &p
+}
+
+// stackFunction strips the arguments from a stack trace's function line,
+// e.g. "example.com/pn.TestSlow(0x1c6af2e2a488?)".
+func stackFunction(line string) string {
+	if strings.HasPrefix(line, "created by ") || !strings.HasSuffix(line, ")") {
+		return line
+	}
+	depth := 0
+	for i := len(line) - 1; i >= 0; i-- {
+		switch line[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// goRoot returns the Go root a runtime or testing frame's file lives
+// under, e.g. "/usr/local/go" for /usr/local/go/src/runtime/panic.go.
+func goRoot(f stackFrame) (string, bool) {
+	pkg, _, _ := strings.Cut(f.function, ".")
+	switch pkg {
+	case "panic":
+		pkg = "runtime"
+	case "runtime", "testing":
+	default:
+		return "", false
+	}
+	i := strings.LastIndex(f.file, "/src/"+pkg+"/")
+	if i < 0 {
+		return "", false
+	}
+	return f.file[:i], true
+}
+
+// userFrame returns the first frame outside the standard library and the
+// generated test main: one whose file is not under goroot, or, if the
+// trace never showed the Go root, one whose import path starts with a
+// domain such as example.com.
+func userFrame(frames []stackFrame, goroot string) *stackFrame {
+	for i, f := range frames {
+		if strings.HasPrefix(f.function, "created by ") || f.file == "_testmain.go" {
+			continue
+		}
+		if goroot != "" {
+			if f.file != "" && !strings.HasPrefix(f.file, goroot+"/src/") {
+				return &frames[i]
+			}
+			continue
+		}
+		if first, _, ok := strings.Cut(f.function, "/"); ok && strings.Contains(first, ".") {
+			return &frames[i]
+		}
+	}
+	return nil
+}
+
+// userFrame returns the first frame of the kept goroutine outside the
+// standard library.
+func (p *goPanic) userFrame() *stackFrame {
+	return userFrame(p.frames, p.goroot)
+}
+
+// diagnostics returns the panic as YAML diagnostics: its value, the
+// goroutine and its frames, and the file and line of the first frame
+// outside the standard library.
+func (p *goPanic) diagnostics() map[string]string {
+	diag := map[string]string{"panic": p.value}
+	if p.timeout != "" {
+		diag["timeout"] = p.timeout
+	}
+	if len(p.running) > 0 {
+		diag["running"] = strings.Join(p.running, "\n")
+	}
+	if p.goroutine != "" {
+		diag["goroutine"] = p.goroutine
+	}
+	if len(p.frames) > 0 {
+		frames := make([]string, len(p.frames))
+		for i, f := range p.frames {
+			frames[i] = f.function
+			if f.file != "" {
+				frames[i] += " (" + f.file + ":" + f.line + ")"
+			}
+		}
+		diag["frames"] = strings.Join(frames, "\n")
+	}
+	if f := p.userFrame(); f != nil && f.file != "" {
+		diag["file"] = f.file
+		diag["line"] = f.line
+	}
+	return diag
+}
+
+// isRunning reports whether the panic says name was running when the test
+// binary timed out.
+func (p *goPanic) isRunning(name string) bool {
+	for _, r := range p.running {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// beforePanic returns a test's output up to the panic it ended with, if any.
+func beforePanic(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+			return strings.Join(lines[:i], "\n")
+		}
+	}
+	return output
+}
@@ -0,0 +1,153 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+const nilMapPanic = `--- FAIL: TestPanic (0.00s)
+panic: assignment to entry in nil map [recovered, repanicked]
+
+goroutine 8 [running]:
+testing.tRunner.func1.2({0x6b6ea0, 0x6ef0c0})
+	/usr/local/go/src/testing/testing.go:2123 +0x232
+panic({0x6b6ea0?, 0x6ef0c0?})
+	/usr/local/go/src/runtime/panic.go:859 +0x125
+example.com/pn.TestPanic.func1(0x2bd16fe4c6c8?)
+	/tmp/pn/p_test.go:14 +0x28
+testing.tRunner(0x2bd16fe4c6c8, 0x6d4940)
+	/usr/local/go/src/testing/testing.go:2193 +0xea
+created by testing.(*T).Run in goroutine 7
+	/usr/local/go/src/testing/testing.go:2258 +0x4d4
+`
+
+const timeoutPanic = `=== RUN   TestSlow
+panic: test timed out after 2s
+	running tests:
+		TestSlow (2s)
+		TestOther (1s)
+
+goroutine 8 [running]:
+testing.(*M).startAlarm.func1()
+	/usr/local/go/src/testing/testing.go:2959 +0x34a
+created by time.goFunc
+	/usr/local/go/src/time/sleep.go:182 +0x2d
+
+goroutine 7 [sleep]:
+time.Sleep(0x2540be400)
+	/usr/local/go/src/runtime/time.go:368 +0x165
+example.com/pn.TestSlow(0x1c6af2e2a488?)
+	/tmp/pn/p_test.go:19 +0x1d
+testing.tRunner(0x1c6af2e2a488, 0x6d4898)
+	/usr/local/go/src/testing/testing.go:2193 +0xea
+`
+
+// dotlessTimeoutPanic is from a module declared as "module pn", with the
+// main goroutine still waiting on the test.
+const dotlessTimeoutPanic = `panic: test timed out after 1s
+	running tests:
+		TestSlow (1s)
+
+goroutine 7 [running]:
+testing.(*M).startAlarm.func1()
+	/usr/local/go/src/testing/testing.go:2959 +0x34a
+created by time.goFunc
+	/usr/local/go/src/time/sleep.go:182 +0x2d
+
+goroutine 1 [chan receive]:
+testing.(*T).Run(0x1bb18b586008, {0x554bc5?, 0x1bb18b53eaa0?}, 0x6d46f8)
+	/usr/local/go/src/testing/testing.go:2266 +0x4f2
+testing.(*M).Run(0x1bb18b558820)
+	/usr/local/go/src/testing/testing.go:2600 +0x6af
+main.main()
+	_testmain.go:46 +0x9b
+
+goroutine 6 [sleep]:
+time.Sleep(0x12a05f200)
+	/usr/local/go/src/runtime/time.go:368 +0x165
+pn.TestSlow(0x1bb18b586248?)
+	/tmp/pnd/p_test.go:8 +0x1d
+testing.tRunner(0x1bb18b586248, 0x6d46f8)
+	/usr/local/go/src/testing/testing.go:2193 +0xea
+created by testing.(*T).Run in goroutine 1
+	/usr/local/go/src/testing/testing.go:2258 +0x4d4
+`
+
+func TestParseGoPanic(t *testing.T) {
+	p := parseGoPanic(nilMapPanic)
+	if p == nil {
+		t.Fatal("expected a panic")
+	}
+	diag := p.diagnostics()
+	want := map[string]string{
+		"panic":     "assignment to entry in nil map",
+		"goroutine": "8 [running]",
+		"file":      "/tmp/pn/p_test.go",
+		"line":      "14",
+		"frames": "testing.tRunner.func1.2 (/usr/local/go/src/testing/testing.go:2123)\n" +
+			"panic (/usr/local/go/src/runtime/panic.go:859)\n" +
+			"example.com/pn.TestPanic.func1 (/tmp/pn/p_test.go:14)\n" +
+			"testing.tRunner (/usr/local/go/src/testing/testing.go:2193)\n" +
+			"created by testing.(*T).Run in goroutine 7 (/usr/local/go/src/testing/testing.go:2258)",
+	}
+	for k, v := range want {
+		if diag[k] != v {
+			t.Errorf("diag[%q] = %q, want %q", k, diag[k], v)
+		}
+	}
+	if _, ok := diag["timeout"]; ok {
+		t.Errorf("unexpected timeout in %v", diag)
+	}
+}
+
+func TestParseGoPanicTimeout(t *testing.T) {
+	p := parseGoPanic(timeoutPanic)
+	if p == nil {
+		t.Fatal("expected a panic")
+	}
+	if p.timeout != "2s" {
+		t.Errorf("timeout = %q, want 2s", p.timeout)
+	}
+	if !p.isRunning("TestSlow") || !p.isRunning("TestOther") || p.isRunning("TestOK") {
+		t.Errorf("running = %q", p.running)
+	}
+	// The alarm goroutine is skipped for the one running the test.
+	diag := p.diagnostics()
+	if diag["goroutine"] != "7 [sleep]" || diag["file"] != "/tmp/pn/p_test.go" || diag["line"] != "19" {
+		t.Errorf("diag = %v", diag)
+	}
+}
+
+func TestParseGoPanicDotlessModule(t *testing.T) {
+	// A module named without a domain, as in "module pn", looks like the
+	// standard library by import path alone.
+	dotless := strings.ReplaceAll(nilMapPanic, "example.com/pn.", "pn.")
+	diag := parseGoPanic(dotless).diagnostics()
+	if diag["file"] != "/tmp/pn/p_test.go" || diag["line"] != "14" {
+		t.Errorf("diag = %v", diag)
+	}
+
+	diag = parseGoPanic(dotlessTimeoutPanic).diagnostics()
+	if diag["goroutine"] != "6 [sleep]" || diag["file"] != "/tmp/pnd/p_test.go" || diag["line"] != "8" {
+		t.Errorf("diag = %v", diag)
+	}
+}
+
+func TestParseGoPanicFatalError(t *testing.T) {
+	p := parseGoPanic("fatal error: concurrent map writes\n\ngoroutine 21 [running]:\nexample.com/pn.TestRace.func1()\n\t/tmp/pn/p_test.go:30 +0x45\n")
+	if p == nil || p.value != "concurrent map writes" || p.goroutine != "21 [running]" {
+		t.Fatalf("parseGoPanic = %+v", p)
+	}
+}
+
+func TestParseGoPanicNone(t *testing.T) {
+	if p := parseGoPanic("=== RUN   TestA\n    a_test.go:5: panic: not really\n--- FAIL: TestA (0.00s)\n"); p != nil {
+		t.Errorf("expected no panic, got %+v", p)
+	}
+}
+
+func TestBeforePanic(t *testing.T) {
+	if got, want := beforePanic("=== RUN   TestA\n    a_test.go:5: setting up\npanic: boom\n\ngoroutine 1 [running]:\n"), "=== RUN   TestA\n    a_test.go:5: setting up"; got != want {
+		t.Errorf("beforePanic = %q, want %q", got, want)
+	}
+}
@@ -2,21 +2,26 @@ package tap
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
 type testEvent struct {
-	Time    time.Time `json:"Time"`
-	Action  string    `json:"Action"`
-	Package string    `json:"Package"`
-	Test    string    `json:"Test"`
-	Elapsed float64   `json:"Elapsed"`
-	Output  string    `json:"Output"`
+	Time        time.Time `json:"Time"`
+	Action      string    `json:"Action"`
+	Package     string    `json:"Package"`
+	ImportPath  string    `json:"ImportPath"`
+	Test        string    `json:"Test"`
+	Elapsed     float64   `json:"Elapsed"`
+	Output      string    `json:"Output"`
+	FailedBuild string    `json:"FailedBuild"`
 }
 
 type testResult struct {
@@ -24,6 +29,15 @@ type testResult struct {
 	action  string // pass, fail, skip
 	elapsed float64
 	output  strings.Builder
+	emitted bool
+	// benches holds every result line of a benchmark, one per -count
+	// run and -cpu value.
+	benches []*benchResult
+	// Times of the test's run event and its pass, fail or skip.
+	started, ended time.Time
+	// run counts the earlier runs of the top-level test, under -count,
+	// that this result and its subtests follow.
+	run int
 }
 
 type packageResult struct {
@@ -32,30 +46,138 @@ type packageResult struct {
 	testMap map[string]*testResult
 	output  strings.Builder
 	failed  bool
+	skipped bool
 	elapsed float64
+
+	buildFailed bool
+	failedBuild string
+	buildOutput string
+
+	// benchLine is the start of a benchmark result line whose end is
+	// still to come in a later output event.
+	benchLine string
+
+	// crash is the panic that ended the test binary, if any.
+	crash *goPanic
+
+	// Stream mode state: the open subtest, finished top-level tests held
+	// until it opens, and whether the package has finished or is queued.
+	sub      *Writer
+	finished []*testResult
+	done     bool
+	queued   bool
+	// closed is set once the package's own test point is written.
+	closed bool
 }
 
-var fileLineRe = regexp.MustCompile(`(\w[\w_]*\.go):(\d+):`)
+var (
+	// a_test.go:9: got 1, want 2
+	locationRe = regexp.MustCompile(`^(\S+\.go):(\d+):(?:\s(.*))?$`)
+	// ./foo_test.go:5:2: undefined: x
+	compileErrorRe = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.*)$`)
+	// ok  	example.com/foo	(cached)
+	cachedRe = regexp.MustCompile(`(?m)^ok\s+\S+\s+\(cached\)`)
+)
 
-func parseFileLine(output string) (file string, line string) {
-	m := fileLineRe.FindStringSubmatch(output)
-	if m != nil {
-		return m[1], m[2]
+// failureLocations returns the file:line: locations in a test's output,
+// such as those of its t.Errorf calls, in order. Each carries the message
+// after it and the more deeply indented lines continuing that message.
+func failureLocations(raw string) []Location {
+	var locations []Location
+	// indent is that of the location line whose message is being read.
+	indent := -1
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		depth := len(line) - len(trimmed)
+		if m := locationRe.FindStringSubmatch(trimmed); m != nil {
+			locations = append(locations, Location{File: m[1], Line: m[2], Message: m[3]})
+			indent = depth
+			continue
+		}
+		if indent >= 0 && depth > indent {
+			loc := &locations[len(locations)-1]
+			loc.Message += "\n" + line[min(depth, indent+4):]
+			continue
+		}
+		indent = -1
 	}
-	return "", ""
+	for i := range locations {
+		locations[i].Message = strings.Trim(locations[i].Message, "\n")
+	}
+	return locations
+}
+
+// GoTestOptions configures ConvertGoTestWithOptions.
+type GoTestOptions struct {
+	// Verbose passes -v to go test and adds YAML diagnostics to passing
+	// tests: their elapsed time, output such as t.Log lines, and the times
+	// they started and ended.
+	Verbose bool
+	// Stream writes each top-level test, with its subtests, as soon as it
+	// finishes instead of when its package finishes. One package streams
+	// at a time; the finished tests of packages running alongside it are
+	// held until it closes, so the output stays valid TAP-14. Runs of a
+	// test after the first, under -count, are written as "Name (run N)"
+	// rather than grouped under the test.
+	Stream bool
+	// Thresholds fail benchmarks whose metrics break these limits.
+	Thresholds []BenchThreshold
+	// CoverageThresholds add a test point for each package with coverage,
+	// after all packages, that fails when its coverage is below the
+	// minimum.
+	CoverageThresholds []CoverageThreshold
+	// CoverProfile is the coverage profile go test writes. Its per-package
+	// coverage, merged across runs, takes precedence over the percentages
+	// in package output, both in each package's YAML and, read again once
+	// the stream ends, for the thresholds.
+	CoverProfile string
+	// ResolveFile, if set, maps a file named in a failed test's output,
+	// e.g. "a_test.go", of the package with import path pkg to the path to
+	// report, such as one relative to the repository root. See
+	// GoListResolver, and ImportPathResolver for saved output.
+	ResolveFile func(pkg, file string) string
 }
 
 // ConvertGoTest reads go test -json events from r and writes TAP-14 to w.
-// If verbose is true, passing tests include output diagnostics.
+// If verbose is true, passing tests include YAML diagnostics.
 // Returns an exit code: 0 for all pass, 1 for any failure, 2 for build errors.
 func ConvertGoTest(r io.Reader, w io.Writer, verbose bool) int {
-	scanner := bufio.NewScanner(r)
+	return ConvertGoTestWithOptions(r, w, GoTestOptions{Verbose: verbose})
+}
+
+// goTestConverter holds the state of one ConvertGoTestWithOptions run.
+type goTestConverter struct {
+	tw       *Writer
+	opts     GoTestOptions
+	exitCode int
 
-	packages := make(map[string]*packageResult)
-	var packageOrder []string
+	packages map[string]*packageResult
+	order    []*packageResult
+	// Compiler output by import path, from Go 1.24's build-output events.
+	buildOutput map[string]*strings.Builder
 
-	tw := NewWriter(w)
-	exitCode := 0
+	// In stream mode, current is the package whose subtest is open, and
+	// queue holds packages with finished tests or results waiting for it
+	// to close, in the order they first had one.
+	current *packageResult
+	queue   []*packageResult
+
+	// Statement coverage by package, in the order packages finished.
+	coverage      map[string]float64
+	coverageOrder []string
+	// profile holds the part of opts.CoverProfile parsed so far, the
+	// first profileRead bytes.
+	profile     *coverProfile
+	profileRead int64
+}
+
+// ConvertGoTestWithOptions reads go test -json events from r and writes
+// TAP-14 to w. Each package becomes a subtest and each test a test point,
+// with subtests nested beneath their parent test.
+// Returns an exit code: 0 for all pass, 1 for any failure, 2 for build errors.
+func ConvertGoTestWithOptions(r io.Reader, w io.Writer, opts GoTestOptions) int {
+	scanner := bufio.NewScanner(r)
+	c := newGoTestConverter(w, opts)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -65,148 +187,819 @@ func ConvertGoTest(r io.Reader, w io.Writer, verbose bool) int {
 
 		var ev testEvent
 		if err := json.Unmarshal([]byte(line), &ev); err != nil {
-			tw.Comment(fmt.Sprintf("unparseable: %s", line))
+			c.tw.Comment(fmt.Sprintf("unparseable: %s", line))
 			continue
 		}
+		c.handle(ev)
+	}
+	return c.finish()
+}
 
-		pkg := packages[ev.Package]
-		if pkg == nil {
-			pkg = &packageResult{
-				name:    ev.Package,
-				testMap: make(map[string]*testResult),
-			}
-			packages[ev.Package] = pkg
-			packageOrder = append(packageOrder, ev.Package)
-		}
-
-		if ev.Test == "" {
-			// Package-level event
-			switch ev.Action {
-			case "output":
-				pkg.output.WriteString(ev.Output)
-			case "pass":
-				pkg.elapsed = ev.Elapsed
-				emitPackage(tw, pkg, verbose)
-			case "fail":
-				pkg.failed = true
-				pkg.elapsed = ev.Elapsed
-				emitPackage(tw, pkg, verbose)
-				if exitCode < 1 {
-					exitCode = 1
-				}
+func newGoTestConverter(w io.Writer, opts GoTestOptions) *goTestConverter {
+	return &goTestConverter{
+		tw:          NewWriter(w),
+		opts:        opts,
+		packages:    make(map[string]*packageResult),
+		buildOutput: make(map[string]*strings.Builder),
+		coverage:    make(map[string]float64),
+	}
+}
+
+// finish writes what remains once the events end, and returns the exit
+// code.
+func (c *goTestConverter) finish() int {
+	// Packages still streaming or queued, or never heard from again, did
+	// not finish: the stream ended early, e.g. because go test was killed.
+	for len(c.queue) > 0 || c.current != nil {
+		if c.current == nil {
+			c.current = c.queue[0]
+			c.queue = c.queue[1:]
+		}
+		c.finishPackage(c.current)
+		c.current = nil
+	}
+	for _, pkg := range c.order {
+		if !pkg.closed {
+			c.finishPackage(pkg)
+		}
+	}
+
+	c.coverageGates()
+	c.tw.Plan()
+	return c.exitCode
+}
+
+func (c *goTestConverter) handle(ev testEvent) {
+	if ev.Action == "build-output" || ev.Action == "build-fail" {
+		if ev.Action == "build-output" {
+			out := c.buildOutput[ev.ImportPath]
+			if out == nil {
+				out = &strings.Builder{}
+				c.buildOutput[ev.ImportPath] = out
 			}
-			continue
+			out.WriteString(ev.Output)
 		}
+		return
+	}
 
-		// Test-level event
-		tr := pkg.testMap[ev.Test]
-		if tr == nil {
-			tr = &testResult{name: ev.Test}
-			pkg.testMap[ev.Test] = tr
-			pkg.tests = append(pkg.tests, tr)
+	pkg := c.packages[ev.Package]
+	if pkg == nil {
+		pkg = &packageResult{
+			name:    ev.Package,
+			testMap: make(map[string]*testResult),
 		}
+		c.packages[ev.Package] = pkg
+		c.order = append(c.order, pkg)
+	}
 
+	if ev.Test == "" {
+		// Package-level event
 		switch ev.Action {
 		case "output":
-			tr.output.WriteString(ev.Output)
+			pkg.output.WriteString(ev.Output)
+			// Older Go versions write benchmark results as package output.
+			c.benchOutput(pkg, ev.Output)
 		case "pass":
-			tr.action = "pass"
-			tr.elapsed = ev.Elapsed
+			pkg.elapsed = ev.Elapsed
+			c.packageDone(pkg)
 		case "fail":
-			tr.action = "fail"
-			tr.elapsed = ev.Elapsed
+			pkg.failed = true
+			pkg.elapsed = ev.Elapsed
+			if ev.FailedBuild != "" || isBuildFailure(pkg.output.String()) {
+				pkg.buildFailed = true
+				pkg.failedBuild = ev.FailedBuild
+				if out := c.buildOutput[ev.FailedBuild]; out != nil {
+					pkg.buildOutput = out.String()
+				}
+			}
+			c.packageDone(pkg)
 		case "skip":
-			tr.action = "skip"
-			tr.elapsed = ev.Elapsed
+			// Before Go 1.22 only packages without test files skip.
+			pkg.skipped = true
+			pkg.elapsed = ev.Elapsed
+			c.packageDone(pkg)
+		}
+		return
+	}
+
+	// Test-level event
+	tr := pkg.testMap[ev.Test]
+	if tr != nil && ev.Action == "run" && tr.action != "" && isRerun(ev.Test) {
+		// -count runs the test again: its results so far stay with the
+		// earlier run.
+		pkg.forget(ev.Test)
+		tr = nil
+	}
+	if tr == nil {
+		tr = &testResult{name: ev.Test}
+		if top := pkg.testMap[topLevelName(ev.Test)]; top != nil {
+			tr.run = top.run
+		} else if prev := pkg.lastRun(ev.Test); prev != nil {
+			tr.run = prev.run + 1
+		}
+		pkg.testMap[ev.Test] = tr
+		pkg.tests = append(pkg.tests, tr)
+	}
+
+	switch ev.Action {
+	case "run":
+		tr.started = ev.Time
+	case "output":
+		tr.output.WriteString(ev.Output)
+		c.benchOutput(pkg, ev.Output)
+	case "pass", "fail", "skip":
+		tr.action = ev.Action
+		tr.elapsed = ev.Elapsed
+		tr.ended = ev.Time
+		if c.opts.Stream && !strings.Contains(tr.name, "/") {
+			c.testDone(pkg, tr)
+		}
+	}
+}
+
+// benchOutput records benchmark result lines. Benchmarks have no pass
+// event of their own, so the result line is what finishes one. go test
+// writes a result line in two parts, the name before the benchmark runs
+// and the metrics after, which may arrive as separate events.
+func (c *goTestConverter) benchOutput(pkg *packageResult, output string) {
+	output = pkg.benchLine + output
+	pkg.benchLine = ""
+	lines := strings.Split(output, "\n")
+	if last := lines[len(lines)-1]; strings.HasPrefix(strings.TrimSpace(last), "Benchmark") {
+		pkg.benchLine = last
+	}
+	for _, line := range lines[:len(lines)-1] {
+		name, res, ok := parseBenchLine(line)
+		if !ok {
+			continue
 		}
+		tr := pkg.test(name)
+		tr.benches = append(tr.benches, res)
+		if tr.action == "" {
+			tr.action = "pass"
+		}
+	}
+}
+
+// test returns the named test, creating it and any parents it lacks.
+func (pkg *packageResult) test(name string) *testResult {
+	if tr := pkg.testMap[name]; tr != nil {
+		return tr
+	}
+	if i := strings.LastIndex(name, "/"); i > 0 {
+		pkg.test(name[:i])
 	}
+	tr := &testResult{name: name}
+	pkg.testMap[name] = tr
+	pkg.tests = append(pkg.tests, tr)
+	return tr
+}
 
-	tw.Plan()
-	return exitCode
+// isRerun reports whether a test that runs again after finishing is one
+// of go test's -count repetitions. Subtests run again with their parent,
+// and a benchmark's runs are its result lines.
+func isRerun(name string) bool {
+	return !strings.Contains(name, "/") && !strings.HasPrefix(name, "Benchmark")
 }
 
-func emitPackage(tw *Writer, pkg *packageResult, verbose bool) {
-	sub := tw.Subtest(pkg.name)
+func topLevelName(name string) string {
+	top, _, _ := strings.Cut(name, "/")
+	return top
+}
+
+// forget drops a top-level test and its subtests from testMap, so that
+// the events of its next run start new results.
+func (pkg *packageResult) forget(name string) {
+	for key := range pkg.testMap {
+		if key == name || strings.HasPrefix(key, name+"/") {
+			delete(pkg.testMap, key)
+		}
+	}
+}
+
+// lastRun returns the latest run of the named test, if any.
+func (pkg *packageResult) lastRun(name string) *testResult {
+	for i := len(pkg.tests) - 1; i >= 0; i-- {
+		if pkg.tests[i].name == name {
+			return pkg.tests[i]
+		}
+	}
+	return nil
+}
+
+// testDone streams a finished top-level test if its package is the one
+// streaming, or holds it until then.
+func (c *goTestConverter) testDone(pkg *packageResult, tr *testResult) {
+	if c.current == nil {
+		c.start(pkg)
+	}
+	if c.current == pkg {
+		c.emitTopLevel(pkg, tr)
+		return
+	}
+	pkg.finished = append(pkg.finished, tr)
+	c.enqueue(pkg)
+}
+
+// packageDone writes a finished package, or in stream mode holds it until
+// the package streaming now closes.
+func (c *goTestConverter) packageDone(pkg *packageResult) {
+	pkg.done = true
+	if !c.opts.Stream {
+		c.finishPackage(pkg)
+		return
+	}
+	if c.current != nil && c.current != pkg {
+		c.enqueue(pkg)
+		return
+	}
+
+	c.finishPackage(pkg)
+	c.current = nil
+
+	// Write every held package that has finished, then stream the first
+	// one still running.
+	for len(c.queue) > 0 {
+		var next *packageResult
+		for i, q := range c.queue {
+			if q.done {
+				next = q
+				c.queue = append(c.queue[:i], c.queue[i+1:]...)
+				break
+			}
+		}
+		if next == nil {
+			c.start(c.queue[0])
+			c.queue = c.queue[1:]
+			return
+		}
+		c.finishPackage(next)
+	}
+}
+
+func (c *goTestConverter) enqueue(pkg *packageResult) {
+	if !pkg.queued {
+		pkg.queued = true
+		c.queue = append(c.queue, pkg)
+	}
+}
+
+// start makes pkg the streaming package, opening its subtest and writing
+// the tests it finished while waiting.
+func (c *goTestConverter) start(pkg *packageResult) {
+	c.current = pkg
+	pkg.queued = false
+	pkg.sub = c.tw.Subtest(pkg.name)
+	for _, tr := range pkg.finished {
+		c.emitTopLevel(pkg, tr)
+	}
+	pkg.finished = nil
+}
 
+func (c *goTestConverter) emitTopLevel(pkg *packageResult, tr *testResult) {
+	if tr.emitted {
+		return
+	}
+	tr.emitted = true
+
+	// Streaming writes each run as it finishes. Otherwise a test run more
+	// than once under -count is a subtest with a test point per run.
+	var runs []*testResult
+	if !c.opts.Stream {
+		for _, other := range pkg.tests {
+			if other.name == tr.name && other.run > tr.run && !other.emitted {
+				other.emitted = true
+				runs = append(runs, other)
+			}
+		}
+	}
+	if len(runs) == 0 {
+		desc := ""
+		if tr.run > 0 {
+			desc = fmt.Sprintf("%s (run %d)", tr.name, tr.run+1)
+		}
+		if emitTest(pkg.sub, pkg, tr, desc, c.opts) {
+			pkg.failed = true
+		}
+		return
+	}
+
+	sub := pkg.sub.Subtest(tr.name)
+	failed := false
+	for _, run := range append([]*testResult{tr}, runs...) {
+		failed = emitTest(sub, pkg, run, fmt.Sprintf("run %d", run.run+1), c.opts) || failed
+	}
+	sub.Plan()
+	if failed {
+		pkg.sub.NotOk(tr.name, nil)
+		pkg.failed = true
+	} else {
+		pkg.sub.Ok(tr.name)
+	}
+}
+
+// finishPackage writes the rest of a package: its subtest if not yet open,
+// the top-level tests not yet written, its plan and its own test point.
+func (c *goTestConverter) finishPackage(pkg *packageResult) {
+	pkg.closed = true
+	if pkg.buildFailed && pkg.sub == nil {
+		emitBuildFailure(c.tw, pkg, pkg.failedBuild, pkg.buildOutput)
+		c.exitCode = 2
+		return
+	}
+
+	// Tests still without a result were running when the panic, if any,
+	// ended the test binary.
+	for _, tr := range pkg.tests {
+		if pkg.crash = parseGoPanic(tr.output.String()); pkg.crash != nil {
+			break
+		}
+	}
+	if pkg.crash == nil {
+		pkg.crash = parseGoPanic(pkg.output.String())
+	}
+
+	output := pkg.output.String()
+	diag := make(map[string]string)
+	if cachedRe.MatchString(output) {
+		diag["cached"] = "true"
+	}
+	// Packages without test files still report coverage, 0% under -cover,
+	// and the thresholds apply to them too.
+	if pct, ok := c.packageCoverage(pkg.name, output); ok {
+		diag["coverage"] = formatCoverage(pct)
+		c.coverage[pkg.name] = pct
+		c.coverageOrder = append(c.coverageOrder, pkg.name)
+	}
+
+	if pkg.sub == nil && hasNoTestFiles(pkg) {
+		c.tw.SkipWithDiagnostics(pkg.name, "no test files", diag)
+		return
+	}
+
+	if pkg.sub == nil {
+		pkg.sub = c.tw.Subtest(pkg.name)
+	}
+	for _, tr := range pkg.finished {
+		c.emitTopLevel(pkg, tr)
+	}
 	for _, tr := range pkg.tests {
 		// Skip subtests -- they are emitted by their parent
 		if strings.Contains(tr.name, "/") {
 			continue
 		}
-		emitTest(sub, pkg, tr, verbose)
+		c.emitTopLevel(pkg, tr)
 	}
 
-	sub.Plan()
+	// -run or -skip filtered out every test.
+	noTestsRun := len(pkg.tests) == 0 && !pkg.failed && pkg.done &&
+		strings.Contains(output, "no tests to run")
+	if noTestsRun {
+		pkg.sub.SkipAll("no tests to run")
+	} else {
+		pkg.sub.Plan()
+	}
+
+	// A panic outside any test, e.g. in an init function or TestMain,
+	// belongs to the package.
+	if p := parseGoPanic(output); p != nil && pkg.failed {
+		for k, v := range p.diagnostics() {
+			diag[k] = v
+		}
+	}
+	if !pkg.done {
+		pkg.failed = true
+		diag["message"] = "package did not finish"
+	}
 
 	if pkg.failed {
-		tw.NotOk(pkg.name, nil)
+		c.tw.NotOk(pkg.name, diag)
+		c.exitCode = max(c.exitCode, 1)
+	} else if noTestsRun {
+		c.tw.SkipWithDiagnostics(pkg.name, "no tests to run", diag)
 	} else {
-		tw.Ok(pkg.name)
+		c.tw.OkWithDiagnostics(pkg.name, diag)
+	}
+}
+
+// packageCoverage returns a finished package's statement coverage: from
+// the coverage profile if one is given and already holds the package, as
+// go test merges each package into it before reporting the package, and
+// otherwise from the package's output.
+func (c *goTestConverter) packageCoverage(name, output string) (float64, bool) {
+	if c.opts.CoverProfile != "" && c.readCoverProfile(false) == nil {
+		if pct, ok := c.profile.percent(name); ok {
+			return pct, true
+		}
+	}
+	return parseCoverage(output)
+}
+
+// readCoverProfile parses whatever opts.CoverProfile has gained since it
+// was last read, so that each line is parsed once however many packages
+// look it up. Until final, a trailing line go test is still writing is
+// left for the next read.
+func (c *goTestConverter) readCoverProfile(final bool) error {
+	if c.profile == nil {
+		c.profile = newCoverProfile()
+	}
+	f, err := os.Open(c.opts.CoverProfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(c.profileRead, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if !final {
+		data = data[:bytes.LastIndexByte(data, '\n')+1]
+	}
+	c.profileRead += int64(len(data))
+	return c.profile.parse(bytes.NewReader(data))
+}
+
+// coverageGates writes a test point for each package with a coverage
+// threshold, failing those whose coverage is below it.
+func (c *goTestConverter) coverageGates() {
+	if len(c.opts.CoverageThresholds) == 0 {
+		return
+	}
+
+	names := c.coverageOrder
+	if c.opts.CoverProfile != "" {
+		if err := c.readCoverProfile(true); err != nil {
+			c.tw.Comment(fmt.Sprintf("coverage profile: %v", err))
+		}
+		var extra []string
+		for name, pct := range c.profile.coverage() {
+			if _, ok := c.coverage[name]; !ok {
+				extra = append(extra, name)
+			}
+			c.coverage[name] = pct
+		}
+		sort.Strings(extra)
+		names = append(names, extra...)
+	}
+
+	for _, name := range names {
+		min, ok := coverageMinimum(name, c.opts.CoverageThresholds)
+		if !ok {
+			continue
+		}
+		pct := c.coverage[name]
+		diag := map[string]string{
+			"coverage": formatCoverage(pct),
+			"minimum":  formatCoverage(min),
+		}
+		desc := "coverage " + name
+		if pct < min {
+			diag["message"] = fmt.Sprintf("coverage %s%% is below the %s%% minimum", formatCoverage(pct), formatCoverage(min))
+			c.tw.NotOk(desc, diag)
+			c.exitCode = max(c.exitCode, 1)
+		} else {
+			c.tw.OkWithDiagnostics(desc, diag)
+		}
+	}
+}
+
+// hasNoTestFiles reports whether a finished package had no test files:
+// go test either skips it, or with -cover passes it without running a test
+// binary, which would have printed PASS.
+func hasNoTestFiles(pkg *packageResult) bool {
+	if pkg.skipped {
+		return true
+	}
+	if len(pkg.tests) > 0 || pkg.failed || !pkg.done {
+		return false
+	}
+	output := pkg.output.String()
+	if strings.Contains(output, "[no test files]") {
+		return true
+	}
+	// Plain go test output says ok even when it does not list the tests.
+	if strings.Contains(output, "\nok  \t") || strings.HasPrefix(output, "ok  \t") {
+		return false
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "PASS" {
+			return false
+		}
+	}
+	return !strings.Contains(output, "no tests to run")
+}
+
+// isBuildFailure reports whether a package's output says it failed to
+// build. Before Go 1.24 this is the only sign, and the compiler errors go to
+// stderr.
+func isBuildFailure(output string) bool {
+	return strings.Contains(output, "[build failed]") || strings.Contains(output, "[setup failed]")
+}
+
+// emitBuildFailure writes a package that failed to build as a subtest
+// holding a not ok test point per compiler error, with its file, line,
+// column and message, followed by a Bail out!.
+func emitBuildFailure(tw *Writer, pkg *packageResult, failedBuild, output string) {
+	sub := tw.Subtest(pkg.name)
+
+	errors := parseCompileErrors(output)
+	if len(errors) == 0 {
+		message := strings.TrimSpace(output)
+		if message == "" {
+			message = "build failed; compiler errors were written to stderr"
+		}
+		errors = append(errors, map[string]string{"message": message})
+	}
+	for _, diag := range errors {
+		desc := "build error"
+		if diag["file"] != "" {
+			desc = diag["file"] + ":" + diag["line"]
+			if diag["column"] != "" {
+				desc += ":" + diag["column"]
+			}
+		}
+		sub.NotOk(desc, diag)
 	}
+
+	reason := "build failed"
+	if failedBuild != "" {
+		reason += ": " + failedBuild
+	}
+	sub.BailOut(reason)
+
+	tw.NotOk(pkg.name, map[string]string{"message": reason})
 }
 
-func emitTest(tw *Writer, pkg *packageResult, tr *testResult, verbose bool) {
+// parseCompileErrors splits go build output into one diagnostic per
+// error. Indented lines continue the error before them; "# pkg" headers are
+// dropped.
+func parseCompileErrors(output string) []map[string]string {
+	var errors []map[string]string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		if m := compileErrorRe.FindStringSubmatch(line); m != nil {
+			diag := map[string]string{"file": m[1], "line": m[2], "message": m[4]}
+			if m[3] != "" {
+				diag["column"] = m[3]
+			}
+			errors = append(errors, diag)
+			continue
+		}
+		if len(errors) > 0 && (line[0] == '\t' || line[0] == ' ') {
+			last := errors[len(errors)-1]
+			last["message"] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		errors = append(errors, map[string]string{"message": line})
+	}
+	return errors
+}
+
+// emitTest writes a test and its subtests, and reports whether it failed.
+// desc, if not empty, replaces the test's name in its test point.
+func emitTest(tw *Writer, pkg *packageResult, tr *testResult, desc string, opts GoTestOptions) bool {
 	// Check for child subtests
 	prefix := tr.name + "/"
 	var children []*testResult
 	for _, child := range pkg.tests {
-		if strings.HasPrefix(child.name, prefix) && !strings.Contains(child.name[len(prefix):], "/") {
+		if child.run == tr.run && strings.HasPrefix(child.name, prefix) && !strings.Contains(child.name[len(prefix):], "/") {
 			children = append(children, child)
 		}
 	}
 
+	// Fuzzing status lines become comments ahead of the test point.
+	for _, line := range fuzzProgress(tr.output.String()) {
+		tw.Comment(line)
+	}
+
+	name := desc
+	if name == "" {
+		name = tr.name
+	}
+
 	if len(children) > 0 {
-		sub := tw.Subtest(tr.name)
+		sub := tw.Subtest(name)
+		// Benchmarks have no result of their own, only their
+		// sub-benchmarks do.
+		unfinished := tr.action == "" && !strings.HasPrefix(tr.name, "Benchmark")
+		failed := tr.action == "fail" || unfinished
 		for _, child := range children {
-			emitTest(sub, pkg, child, verbose)
+			failed = emitTest(sub, pkg, child, "", opts) || failed
 		}
 		sub.Plan()
-		if tr.action == "fail" {
-			tw.NotOk(tr.name, nil)
+		if failed {
+			var diag map[string]string
+			var locations []Location
+			raw := tr.output.String()
+			if unfinished {
+				diag = unfinishedDiagnostics(pkg, tr, opts)
+			} else if parseGoPanic(raw) != nil || len(failureLocations(beforePanic(raw))) > 0 {
+				// The parent failed on its own account too.
+				diag, locations = failureDiagnostics(pkg, tr, opts)
+			}
+			tw.NotOkWithLocations(name, diag, locations)
+		} else if opts.Verbose && tr.action == "pass" {
+			tw.OkWithDiagnostics(name, passDiagnostics(tr, cleanTestOutput(tr.output.String())))
 		} else {
-			tw.Ok(tr.name)
+			tw.Ok(name)
 		}
-		return
+		return failed
 	}
 
 	// Leaf test
-	name := tr.name
 	// For display, use just the last segment
-	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+	if idx := strings.LastIndex(name, "/"); desc == "" && idx >= 0 {
 		name = tr.name[idx+1:]
 	}
 
 	output := cleanTestOutput(tr.output.String())
 
-	switch tr.action {
-	case "pass":
-		tw.Ok(name)
-	case "fail":
-		diag := map[string]string{
-			"elapsed": fmt.Sprintf("%.3f", tr.elapsed),
-			"package": pkg.name,
+	if len(tr.benches) > 0 && tr.action != "fail" && tr.action != "skip" {
+		if len(tr.benches) == 1 {
+			return emitBenchResult(tw, pkg, tr, name, tr.benches[0], opts)
 		}
-		if output != "" {
-			diag["message"] = output
+		// Each -count run and -cpu value is a test point of its own, so
+		// every sample is checked against the thresholds.
+		sub := tw.Subtest(name)
+		failed := false
+		for i, res := range tr.benches {
+			desc := fmt.Sprintf("run %d", i+1)
+			if res.procs != "" {
+				desc += " (procs " + res.procs + ")"
+			}
+			failed = emitBenchResult(sub, pkg, tr, desc, res, opts) || failed
+		}
+		sub.Plan()
+		if failed {
+			tw.NotOk(name, nil)
+		} else {
+			tw.Ok(name)
 		}
-		file, line := parseFileLine(output)
-		if file != "" {
-			diag["file"] = file
-			diag["line"] = line
+		return failed
+	}
+
+	switch tr.action {
+	case "pass":
+		if opts.Verbose {
+			tw.OkWithDiagnostics(name, passDiagnostics(tr, output))
+		} else {
+			tw.Ok(name)
 		}
-		tw.NotOk(name, diag)
+	case "fail":
+		diag, locations := failureDiagnostics(pkg, tr, opts)
+		tw.NotOkWithLocations(name, diag, locations)
+		return true
 	case "skip":
 		reason := extractSkipReason(output)
 		tw.Skip(name, reason)
 	default:
-		tw.Ok(name)
+		// No pass, fail or skip: the test binary panicked, timed out or
+		// was killed while the test ran.
+		tw.NotOk(name, unfinishedDiagnostics(pkg, tr, opts))
+		return true
+	}
+	return false
+}
+
+// emitBenchResult writes one benchmark result with its metrics, failing it
+// if it breaks any threshold.
+func emitBenchResult(tw *Writer, pkg *packageResult, tr *testResult, desc string, res *benchResult, opts GoTestOptions) bool {
+	diag := res.diagnostics()
+	if broken := res.check(tr.name, opts.Thresholds); len(broken) > 0 {
+		diag["package"] = pkg.name
+		diag["message"] = strings.Join(broken, "\n")
+		tw.NotOk(desc, diag)
+		return true
 	}
+	tw.OkWithDiagnostics(desc, diag)
+	return false
+}
+
+// passDiagnostics returns the YAML diagnostics of a passing test in verbose
+// mode: its elapsed time, its output and when it started and ended.
+func passDiagnostics(tr *testResult, output string) map[string]string {
+	diag := map[string]string{"elapsed": fmt.Sprintf("%.3f", tr.elapsed)}
+	if output != "" {
+		diag["output"] = output
+	}
+	if !tr.started.IsZero() {
+		diag["started"] = tr.started.Format(time.RFC3339Nano)
+	}
+	if !tr.ended.IsZero() {
+		diag["ended"] = tr.ended.Format(time.RFC3339Nano)
+	}
+	return diag
+}
+
+// failureDiagnostics returns the YAML diagnostics of a failed test: its
+// output, the file and line it failed at, and any panic or fuzzing input
+// that failed it, with the locations in its output and panic.
+func failureDiagnostics(pkg *packageResult, tr *testResult, opts GoTestOptions) (map[string]string, []Location) {
+	raw := tr.output.String()
+	output := cleanTestOutput(beforePanic(raw))
+	diag := map[string]string{
+		"elapsed": fmt.Sprintf("%.3f", tr.elapsed),
+		"package": pkg.name,
+	}
+	locations := failureLocations(beforePanic(raw))
+	if len(locations) > 0 {
+		diag["file"] = locations[0].File
+		diag["line"] = locations[0].Line
+	}
+	if p := parseGoPanic(raw); p != nil {
+		for k, v := range p.diagnostics() {
+			// The first reported failure stays the test's location; the
+			// panic site joins the list after it.
+			if (k == "file" || k == "line") && len(locations) > 0 {
+				continue
+			}
+			diag[k] = v
+		}
+		if f := p.userFrame(); f != nil && f.file != "" {
+			locations = append(locations, Location{File: f.file, Line: f.line, Message: "panic: " + p.value})
+		}
+		if output == "" {
+			output = "panic: " + p.value
+		}
+	}
+	if output != "" {
+		diag["message"] = output
+	}
+	for k, v := range fuzzDiagnostics(pkg.name, tr.name, raw) {
+		diag[k] = v
+	}
+	if diag["file"] != "" {
+		diag["file"] = resolveFile(opts, pkg.name, diag["file"])
+	}
+	for i := range locations {
+		locations[i].File = resolveFile(opts, pkg.name, locations[i].File)
+	}
+	return diag, locations
+}
+
+// resolveFile maps a file named in a test's output to the path to report
+// for it, if opts say how.
+func resolveFile(opts GoTestOptions, pkg, file string) string {
+	if opts.ResolveFile == nil {
+		return file
+	}
+	return opts.ResolveFile(pkg, file)
+}
+
+// unfinishedDiagnostics returns the YAML diagnostics of a test that never
+// finished, saying why as far as the output tells: a timeout it was
+// running during, a panic in it, or a panic elsewhere in its package.
+func unfinishedDiagnostics(pkg *packageResult, tr *testResult, opts GoTestOptions) map[string]string {
+	raw := tr.output.String()
+	diag := map[string]string{
+		"package": pkg.name,
+		"message": "test did not finish",
+	}
+	if output := cleanTestOutput(beforePanic(raw)); output != "" {
+		diag["output"] = output
+	}
+
+	p := parseGoPanic(raw)
+	own := p != nil
+	if p == nil {
+		p = pkg.crash
+	}
+	switch {
+	case p == nil:
+		diag["message"] = "test did not finish: the test binary exited while it was running"
+	case p.timeout != "" && (own || p.isRunning(tr.name)):
+		diag["message"] = "test timed out after " + p.timeout
+	case own:
+		diag["message"] = "test did not finish: panic: " + p.value
+	default:
+		diag["message"] = "test did not finish: the test binary panicked while it was running"
+	}
+	if own {
+		for k, v := range p.diagnostics() {
+			diag[k] = v
+		}
+		if diag["file"] != "" {
+			diag["file"] = resolveFile(opts, pkg.name, diag["file"])
+		}
+	} else if p != nil {
+		diag["panic"] = p.value
+	}
+	return diag
 }
 
 func cleanTestOutput(raw string) string {
 	var lines []string
+	var report fuzzReport
 	for _, line := range strings.Split(raw, "\n") {
 		trimmed := strings.TrimSpace(line)
+		if report.match(trimmed) {
+			continue
+		}
 		// Skip go test framework lines
 		if strings.HasPrefix(trimmed, "=== RUN") ||
 			strings.HasPrefix(trimmed, "=== PAUSE") ||
@@ -215,7 +1008,8 @@ func cleanTestOutput(raw string) string {
 			strings.HasPrefix(trimmed, "--- FAIL") ||
 			strings.HasPrefix(trimmed, "--- SKIP") ||
 			trimmed == "PASS" || trimmed == "FAIL" ||
-			trimmed == "" {
+			trimmed == "" ||
+			isFuzzProgress(trimmed) {
 			continue
 		}
 		lines = append(lines, strings.TrimSpace(line))
@@ -230,6 +1024,7 @@ func extractSkipReason(output string) string {
 			continue
 		}
 		if trimmed != "" &&
+			!isFuzzProgress(trimmed) &&
 			!strings.HasPrefix(trimmed, "=== RUN") &&
 			!strings.HasPrefix(trimmed, "=== PAUSE") &&
 			!strings.HasPrefix(trimmed, "=== CONT") {
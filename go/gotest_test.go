@@ -2,8 +2,13 @@ package tap
 
 import (
 	"bytes"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestConvertSinglePackageAllPass(t *testing.T) {
@@ -185,3 +190,846 @@ func TestConvertMultiplePackages(t *testing.T) {
 		t.Fatalf("output is not valid TAP-14:\n%s", out)
 	}
 }
+
+func TestConvertBuildFailure(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"ImportPath":"example.com/foo [example.com/foo.test]","Action":"build-output","Output":"# example.com/foo [example.com/foo.test]\n"}`,
+		`{"ImportPath":"example.com/foo [example.com/foo.test]","Action":"build-output","Output":"./foo_test.go:5:2: undefined: x\n"}`,
+		`{"ImportPath":"example.com/foo [example.com/foo.test]","Action":"build-output","Output":"./foo_test.go:9:10: cannot use s (variable of type string) as int value\n\thave string\n"}`,
+		`{"ImportPath":"example.com/foo [example.com/foo.test]","Action":"build-fail"}`,
+		`{"Action":"start","Package":"example.com/foo"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"FAIL\texample.com/foo [build failed]\n"}`,
+		`{"Action":"fail","Package":"example.com/foo","Elapsed":0,"FailedBuild":"example.com/foo [example.com/foo.test]"}`,
+		`{"Action":"run","Package":"example.com/bar","Test":"TestBar"}`,
+		`{"Action":"pass","Package":"example.com/bar","Test":"TestBar","Elapsed":0.001}`,
+		`{"Action":"pass","Package":"example.com/bar","Elapsed":0.002}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    not ok 1 - ./foo_test.go:5:2\n      ---\n      column: 2\n      file: ./foo_test.go\n      line: 5\n      message: 'undefined: x'\n      ...\n",
+		"      message: |\n        cannot use s (variable of type string) as int value\n        have string\n",
+		"    Bail out! build failed: example.com/foo [example.com/foo.test]\nnot ok 1 - example.com/foo\n",
+		"ok 2 - example.com/bar",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if v := reader.Verdict(); v.ExitCode() != ExitBailedOut {
+		t.Errorf("expected bailed-out verdict, got %+v", v)
+	}
+}
+
+func TestConvertBuildFailureWithoutBuildEvents(t *testing.T) {
+	// Before Go 1.24 compiler errors only reach stderr.
+	jsonEvents := strings.Join([]string{
+		`{"Action":"output","Package":"example.com/foo","Output":"FAIL\texample.com/foo [build failed]\n"}`,
+		`{"Action":"fail","Package":"example.com/foo","Elapsed":0}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "not ok 1 - build error") || !strings.Contains(out, "    Bail out! build failed\n") {
+		t.Errorf("expected build failure subtest, got:\n%s", out)
+	}
+}
+
+func TestConvertStreamInterleavedPackages(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/a","Test":"TestA1"}`,
+		`{"Action":"run","Package":"example.com/b","Test":"TestB1"}`,
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA1","Elapsed":0.001}`,
+		`{"Action":"fail","Package":"example.com/b","Test":"TestB1","Elapsed":0.001}`,
+		`{"Action":"run","Package":"example.com/a","Test":"TestA2"}`,
+		`{"Action":"run","Package":"example.com/a","Test":"TestA2/sub"}`,
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA2/sub","Elapsed":0.001}`,
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA2","Elapsed":0.002}`,
+		`{"Action":"fail","Package":"example.com/b","Elapsed":0.005}`,
+		`{"Action":"pass","Package":"example.com/a","Elapsed":0.006}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{Stream: true})
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	want := `TAP version 14
+    # Subtest: example.com/a
+    ok 1 - TestA1
+        # Subtest: TestA2
+        ok 1 - sub
+        1..1
+    ok 2 - TestA2
+    1..2
+ok 1 - example.com/a
+    # Subtest: example.com/b
+    not ok 1 - TestB1
+      ---
+      elapsed: 0.001
+      package: example.com/b
+      ...
+    1..1
+not ok 2 - example.com/b
+1..2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	reader := NewReader(strings.NewReader(buf.String()))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14: %+v", reader.Diagnostics())
+	}
+}
+
+func TestConvertStreamWritesBeforePackageFinishes(t *testing.T) {
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	done := make(chan int)
+	go func() {
+		done <- ConvertGoTestWithOptions(pr, out, GoTestOptions{Stream: true})
+	}()
+
+	io.WriteString(pw, `{"Action":"run","Package":"example.com/a","Test":"TestA"}`+"\n")
+	io.WriteString(pw, `{"Action":"pass","Package":"example.com/a","Test":"TestA","Elapsed":0.001}`+"\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "ok 1 - TestA") {
+		if time.Now().After(deadline) {
+			t.Fatalf("test not written before its package finished, got:\n%s", out.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	io.WriteString(pw, `{"Action":"pass","Package":"example.com/a","Elapsed":0.002}`+"\n")
+	pw.Close()
+	if code := <-done; code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestConvertBenchmarks(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"start","Package":"example.com/foo"}`,
+		`{"Action":"run","Package":"example.com/foo","Test":"BenchmarkParse"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"BenchmarkParse","Output":"BenchmarkParse\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"BenchmarkParse","Output":"BenchmarkParse-8   \t    1000\t      2477 ns/op\t       16 B/op\t       1 allocs/op\n"}`,
+		`{"Action":"run","Package":"example.com/foo","Test":"BenchmarkCopy"}`,
+		`{"Action":"run","Package":"example.com/foo","Test":"BenchmarkCopy/large"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"BenchmarkCopy/large","Output":"BenchmarkCopy/large-8   \t     500\t      3000 ns/op\t 341.33 MB/s\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"PASS\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.010}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+
+	out := buf.String()
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Fatalf("output is not valid TAP-14:\n%s", out)
+	}
+	for _, want := range []string{
+		"ok 1 - BenchmarkParse",
+		"ns/op: 2477",
+		"B/op: 16",
+		"allocs/op: 1",
+		"iterations: 1000",
+		"procs: 8",
+		"ok 1 - large",
+		"MB/s: 341.33",
+		"ok 2 - BenchmarkCopy",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertBenchmarksFromPackageOutput(t *testing.T) {
+	// Before Go 1.24 benchmark results were package-level output.
+	jsonEvents := strings.Join([]string{
+		`{"Action":"output","Package":"example.com/foo","Output":"goos: linux\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"BenchmarkCopy/large-8   \t     500\t      3000 ns/op\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"PASS\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.010}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+
+	out := buf.String()
+	if !strings.Contains(out, "# Subtest: BenchmarkCopy") || !strings.Contains(out, "ok 1 - large") {
+		t.Errorf("expected BenchmarkCopy/large as a subtest, got:\n%s", out)
+	}
+	if !strings.Contains(out, "ns/op: 3000") {
+		t.Errorf("expected ns/op in output, got:\n%s", out)
+	}
+}
+
+func TestConvertBenchmarkThresholds(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/foo","Test":"BenchmarkParse"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"BenchmarkParse","Output":"BenchmarkParse-8   \t    1000\t      6000 ns/op\n"}`,
+		`{"Action":"run","Package":"example.com/foo","Test":"BenchmarkFast"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"BenchmarkFast","Output":"BenchmarkFast-8   \t    1000\t      10 ns/op\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"PASS\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.010}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		Thresholds: []BenchThreshold{{Pattern: "Benchmark*", Unit: "ns/op", Op: "<=", Limit: 5000}},
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"not ok 1 - BenchmarkParse",
+		"message: ns/op 6000 is not <= 5000",
+		"ok 2 - BenchmarkFast",
+		"not ok 1 - example.com/foo",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertBenchmarkRuns(t *testing.T) {
+	// go test -bench . -count 2 -cpu 1,2 writes the name and the metrics
+	// of a result line as separate events, later ones as package output.
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"bc","Test":"BenchmarkX"}`,
+		`{"Action":"output","Package":"bc","Test":"BenchmarkX","Output":"=== RUN   BenchmarkX\n"}`,
+		`{"Action":"output","Package":"bc","Test":"BenchmarkX","Output":"BenchmarkX\n"}`,
+		`{"Action":"output","Package":"bc","Test":"BenchmarkX","Output":"BenchmarkX     \t"}`,
+		`{"Action":"output","Package":"bc","Test":"BenchmarkX","Output":"     100\t        10.69 ns/op\n"}`,
+		`{"Action":"output","Package":"bc","Output":"BenchmarkX     \t     100\t         2.360 ns/op\n"}`,
+		`{"Action":"output","Package":"bc","Output":"BenchmarkX-2   \t"}`,
+		`{"Action":"output","Package":"bc","Output":"     100\t         3.270 ns/op\n"}`,
+		`{"Action":"output","Package":"bc","Output":"BenchmarkX-2   \t     100\t        17.50 ns/op\n"}`,
+		`{"Action":"output","Package":"bc","Output":"PASS\n"}`,
+		`{"Action":"pass","Package":"bc","Elapsed":0.039}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		Thresholds: []BenchThreshold{{Pattern: "BenchmarkX", Unit: "ns/op", Op: "<=", Limit: 10}},
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"        # Subtest: BenchmarkX\n",
+		"        not ok 1 - run 1\n",
+		"ns/op: 10.69",
+		"        ok 2 - run 2\n",
+		"        ok 3 - run 3 (procs 2)\n",
+		"        not ok 4 - run 4 (procs 2)\n",
+		"message: ns/op 17.5 is not <= 10",
+		"        1..4\n",
+		"    not ok 1 - BenchmarkX\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if !NewReader(strings.NewReader(out)).Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertRepeatedRuns(t *testing.T) {
+	// go test -count 2 runs each test, with its subtests, a second time
+	// under the same names.
+	var events []string
+	for range 2 {
+		events = append(events,
+			`{"Action":"run","Package":"cnt","Test":"TestPass"}`,
+			`{"Action":"output","Package":"cnt","Test":"TestPass","Output":"    c_test.go:5: hello\n"}`,
+			`{"Action":"pass","Package":"cnt","Test":"TestPass","Elapsed":0}`,
+			`{"Action":"run","Package":"cnt","Test":"TestSub"}`,
+			`{"Action":"run","Package":"cnt","Test":"TestSub/b"}`,
+			`{"Action":"output","Package":"cnt","Test":"TestSub/b","Output":"        c_test.go:9: bad 1\n"}`,
+			`{"Action":"fail","Package":"cnt","Test":"TestSub/b","Elapsed":0}`,
+			`{"Action":"fail","Package":"cnt","Test":"TestSub","Elapsed":0}`,
+		)
+	}
+	jsonEvents := strings.Join(append(events, `{"Action":"fail","Package":"cnt","Elapsed":0.004}`), "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{Verbose: true})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"        # Subtest: TestPass\n        ok 1 - run 1\n",
+		"        ok 2 - run 2\n",
+		"    ok 1 - TestPass\n",
+		"            # Subtest: run 1\n            not ok 1 - b\n",
+		"            # Subtest: run 2\n            not ok 1 - b\n",
+		"        not ok 2 - run 2\n        1..2\n    not ok 2 - TestSub\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "hello"); n != 2 {
+		t.Errorf("expected each run's output once, got %d:\n%s", n, out)
+	}
+	if n := strings.Count(out, "message: bad 1"); n != 2 {
+		t.Errorf("expected one location per run, got %d:\n%s", n, out)
+	}
+	if !NewReader(strings.NewReader(out)).Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+
+	buf.Reset()
+	ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{Stream: true})
+	out = buf.String()
+	if !strings.Contains(out, "    ok 3 - TestPass (run 2)\n") || !strings.Contains(out, "    not ok 4 - TestSub (run 2)\n") {
+		t.Errorf("expected streamed runs after the first named by run, got:\n%s", out)
+	}
+}
+
+func TestConvertFuzzFailure(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/fz","Test":"FuzzReverse"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"=== RUN   FuzzReverse\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 2/2 completed, now fuzzing with 8 workers\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"fuzz: minimizing 49-byte failing input file\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"--- FAIL: FuzzReverse (0.02s)\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"    --- FAIL: FuzzReverse (0.00s)\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"        fz_test.go:10: bad input \"x000\"\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"    Failing input written to testdata/fuzz/FuzzReverse/1de061fa29cfbb3d\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"    To re-run:\n"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse","Output":"    go test -run=FuzzReverse/1de061fa29cfbb3d\n"}`,
+		`{"Action":"fail","Package":"example.com/fz","Test":"FuzzReverse","Elapsed":0.02}`,
+		`{"Action":"output","Package":"example.com/fz","Output":"FAIL\n"}`,
+		`{"Action":"fail","Package":"example.com/fz","Elapsed":0.026}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Fatalf("output is not valid TAP-14:\n%s", out)
+	}
+	for _, want := range []string{
+		"    # fuzz: elapsed: 0s, gathering baseline coverage: 2/2 completed, now fuzzing with 8 workers\n",
+		"    # fuzz: minimizing 49-byte failing input file\n",
+		"not ok 1 - FuzzReverse",
+		"corpus_file: testdata/fuzz/FuzzReverse/1de061fa29cfbb3d",
+		"reproduce: go test -run=FuzzReverse/1de061fa29cfbb3d example.com/fz",
+		`message: 'fz_test.go:10: bad input "x000"'`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertFuzzCorpus(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/fz","Test":"FuzzReverse"}`,
+		`{"Action":"run","Package":"example.com/fz","Test":"FuzzReverse/seed#0"}`,
+		`{"Action":"pass","Package":"example.com/fz","Test":"FuzzReverse/seed#0","Elapsed":0}`,
+		`{"Action":"run","Package":"example.com/fz","Test":"FuzzReverse/1de061fa29cfbb3d"}`,
+		`{"Action":"output","Package":"example.com/fz","Test":"FuzzReverse/1de061fa29cfbb3d","Output":"    fz_test.go:10: bad input \"x000\"\n"}`,
+		`{"Action":"fail","Package":"example.com/fz","Test":"FuzzReverse/1de061fa29cfbb3d","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/fz","Test":"FuzzReverse","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/fz","Elapsed":0.004}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+
+	out := buf.String()
+	for _, want := range []string{
+		"# Subtest: FuzzReverse",
+		"ok 1 - seed#0",
+		"not ok 2 - 1de061fa29cfbb3d",
+		"corpus_file: testdata/fuzz/FuzzReverse/1de061fa29cfbb3d",
+		"reproduce: go test -run=FuzzReverse/1de061fa29cfbb3d example.com/fz",
+		"not ok 1 - FuzzReverse",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertCoverage(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/foo","Test":"TestA"}`,
+		`{"Action":"pass","Package":"example.com/foo","Test":"TestA","Elapsed":0.001}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"coverage: 72.5% of statements\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.010}`,
+		`{"Action":"run","Package":"example.com/bar","Test":"TestB"}`,
+		`{"Action":"pass","Package":"example.com/bar","Test":"TestB","Elapsed":0.001}`,
+		`{"Action":"output","Package":"example.com/bar","Output":"coverage: 91.0% of statements\n"}`,
+		`{"Action":"pass","Package":"example.com/bar","Elapsed":0.010}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		CoverageThresholds: []CoverageThreshold{
+			{Pattern: "...", Min: 50},
+			{Pattern: "example.com/foo", Min: 80},
+		},
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Fatalf("output is not valid TAP-14:\n%s", out)
+	}
+	for _, want := range []string{
+		"ok 1 - example.com/foo\n  ---\n  coverage: 72.5\n",
+		"ok 2 - example.com/bar\n  ---\n  coverage: 91.0\n",
+		"not ok 3 - coverage example.com/foo",
+		"message: coverage 72.5% is below the 80.0% minimum",
+		"ok 4 - coverage example.com/bar",
+		"minimum: 50.0",
+		"1..4",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertCoverageNoTestFiles(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"start","Package":"example.com/foo/util"}`,
+		`{"Action":"output","Package":"example.com/foo/util","Output":"\texample.com/foo/util\t\tcoverage: 0.0% of statements\n"}`,
+		`{"Action":"pass","Package":"example.com/foo/util","Elapsed":0.121}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		CoverageThresholds: []CoverageThreshold{{Pattern: "...", Min: 50}},
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"ok 1 - example.com/foo/util # SKIP no test files\n  ---\n  coverage: 0.0\n",
+		"not ok 2 - coverage example.com/foo/util",
+		"message: coverage 0.0% is below the 50.0% minimum",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertCoverageFromProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	err := os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/foo/foo.go:3.20,5.2 3 1\n"+
+		"example.com/foo/foo.go:7.20,9.2 1 0\n"+
+		"example.com/foo/util/util.go:3.14,4.2 1 0\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/foo","Test":"TestA"}`,
+		`{"Action":"pass","Package":"example.com/foo","Test":"TestA","Elapsed":0.001}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"coverage: 10.0% of statements in ./...\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.010}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		CoverageThresholds: []CoverageThreshold{{Pattern: "example.com/foo/...", Min: 50}},
+		CoverProfile:       profile,
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"ok 1 - example.com/foo\n  ---\n  coverage: 75.0\n",
+		"ok 2 - coverage example.com/foo\n  ---\n  coverage: 75.0\n",
+		"not ok 3 - coverage example.com/foo/util",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertCoverProfileReadAsItGrows(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	f, err := os.Create(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := newGoTestConverter(io.Discard, GoTestOptions{CoverProfile: profile})
+
+	// go test has merged foo and is partway through writing bar.
+	f.WriteString("mode: set\nexample.com/foo/foo.go:3.20,5.2 1 1\nexample.com/bar/bar.go:3.20,5.2 1 ")
+	if pct, ok := c.packageCoverage("example.com/foo", ""); !ok || pct != 100 {
+		t.Errorf("foo coverage = %v, %v; want 100", pct, ok)
+	}
+	if pct, ok := c.packageCoverage("example.com/bar", "coverage: 12.0% of statements\n"); !ok || pct != 12 {
+		t.Errorf("bar coverage before its line ends = %v, %v; want 12 from output", pct, ok)
+	}
+
+	f.WriteString("0\nexample.com/bar/bar.go:7.20,9.2 1 1\n")
+	if pct, ok := c.packageCoverage("example.com/bar", ""); !ok || pct != 50 {
+		t.Errorf("bar coverage = %v, %v; want 50", pct, ok)
+	}
+	if c.profile.lineNum != 4 {
+		t.Errorf("parsed %d lines, want each of the 4 once", c.profile.lineNum)
+	}
+}
+
+func TestConvertPanicInSubtest(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/pn","Test":"TestPanic"}`,
+		`{"Action":"run","Package":"example.com/pn","Test":"TestPanic/inner"}`,
+		`{"Action":"fail","Package":"example.com/pn","Test":"TestPanic/inner","Elapsed":0}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestPanic","Output":"panic: assignment to entry in nil map [recovered]\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestPanic","Output":"\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestPanic","Output":"goroutine 8 [running]:\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestPanic","Output":"example.com/pn.TestPanic.func1(0x2bd16fe4c6c8?)\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestPanic","Output":"\t/tmp/pn/p_test.go:14 +0x28\n"}`,
+		`{"Action":"fail","Package":"example.com/pn","Test":"TestPanic","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/pn","Elapsed":0.007}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"not ok 1 - TestPanic\n      ---\n",
+		"panic: assignment to entry in nil map\n",
+		"goroutine: 8 [running]",
+		"file: /tmp/pn/p_test.go",
+		"line: 14",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertTimeout(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/pn","Test":"TestOK"}`,
+		`{"Action":"pass","Package":"example.com/pn","Test":"TestOK","Elapsed":0}`,
+		`{"Action":"run","Package":"example.com/pn","Test":"TestOther"}`,
+		`{"Action":"run","Package":"example.com/pn","Test":"TestSlow"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"panic: test timed out after 2s\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"\trunning tests:\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"\t\tTestOther (2s)\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"\t\tTestSlow (2s)\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"goroutine 7 [sleep]:\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"example.com/pn.TestSlow(0x1c6af2e2a488?)\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Test":"TestSlow","Output":"\t/tmp/pn/p_test.go:19 +0x1d\n"}`,
+		`{"Action":"output","Package":"example.com/pn","Output":"FAIL\texample.com/pn\t2.007s\n"}`,
+		`{"Action":"fail","Package":"example.com/pn","Elapsed":2.007}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Fatalf("output is not valid TAP-14:\n%s", out)
+	}
+	for _, want := range []string{
+		"ok 1 - TestOK\n",
+		"not ok 2 - TestOther\n      ---\n      message: test timed out after 2s\n      package: example.com/pn\n      panic: test timed out after 2s\n      ...\n",
+		"not ok 3 - TestSlow",
+		"timeout: 2s",
+		"running: |\n        TestOther\n        TestSlow\n",
+		"file: /tmp/pn/p_test.go",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertUnfinishedWithoutPanic(t *testing.T) {
+	// The stream ends while TestKilled runs, as when the test binary is
+	// killed by a signal.
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/foo","Test":"TestKilled"}`,
+		`{"Action":"run","Package":"example.com/foo","Test":"TestKilled/sub"}`,
+		`{"Action":"output","Package":"example.com/foo","Test":"TestKilled/sub","Output":"    foo_test.go:9: working\n"}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"not ok 1 - sub",
+		"message: 'test did not finish: the test binary exited while it was running'",
+		"output: 'foo_test.go:9: working'",
+		"not ok 1 - TestKilled",
+		"not ok 1 - example.com/foo",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertPackagesWithoutTests(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		// No test files, before Go 1.22 and without -cover.
+		`{"Action":"start","Package":"example.com/nofiles"}`,
+		`{"Action":"output","Package":"example.com/nofiles","Output":"?   \texample.com/nofiles\t[no test files]\n"}`,
+		`{"Action":"skip","Package":"example.com/nofiles","Elapsed":0}`,
+		// No test files with -cover: no test binary ran.
+		`{"Action":"start","Package":"example.com/covered"}`,
+		`{"Action":"output","Package":"example.com/covered","Output":"\texample.com/covered\t\t"}`,
+		`{"Action":"pass","Package":"example.com/covered","Elapsed":0.5}`,
+		// -run matched no tests, and the result came from the cache.
+		`{"Action":"start","Package":"example.com/filtered"}`,
+		`{"Action":"output","Package":"example.com/filtered","Output":"testing: warning: no tests to run\n"}`,
+		`{"Action":"output","Package":"example.com/filtered","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"example.com/filtered","Output":"ok  \texample.com/filtered\t(cached) [no tests to run]\n"}`,
+		`{"Action":"pass","Package":"example.com/filtered","Elapsed":0}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+
+	out := buf.String()
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Fatalf("output is not valid TAP-14:\n%s", out)
+	}
+	for _, want := range []string{
+		"ok 1 - example.com/nofiles # SKIP no test files\n",
+		"ok 2 - example.com/covered # SKIP no test files\n",
+		"    # Subtest: example.com/filtered\n    1..0 # SKIP no tests to run\n" +
+			"ok 3 - example.com/filtered # SKIP no tests to run\n  ---\n  cached: true\n  ...\n",
+		"1..3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertCachedPackage(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/foo","Test":"TestA"}`,
+		`{"Action":"pass","Package":"example.com/foo","Test":"TestA","Elapsed":0}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"example.com/foo","Output":"ok  \texample.com/foo\t(cached)\n"}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.001}`,
+		`{"Action":"run","Package":"example.com/bar","Test":"TestB"}`,
+		`{"Action":"pass","Package":"example.com/bar","Test":"TestB","Elapsed":0}`,
+		`{"Action":"output","Package":"example.com/bar","Output":"ok  \texample.com/bar\t0.004s\n"}`,
+		`{"Action":"pass","Package":"example.com/bar","Elapsed":0.005}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+
+	out := buf.String()
+	if !strings.Contains(out, "ok 1 - example.com/foo\n  ---\n  cached: true\n  ...\n") {
+		t.Errorf("expected cached YAML on example.com/foo, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "ok 2 - example.com/bar\n1..2\n") {
+		t.Errorf("expected no YAML on example.com/bar, got:\n%s", out)
+	}
+}
+
+func TestConvertVerbosePassingTests(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Time":"2026-10-18T16:16:30.703698604Z","Action":"run","Package":"example.com/foo","Test":"TestA"}`,
+		`{"Time":"2026-10-18T16:16:30.703700000Z","Action":"output","Package":"example.com/foo","Test":"TestA","Output":"=== RUN   TestA\n"}`,
+		`{"Time":"2026-10-18T16:16:30.703800000Z","Action":"output","Package":"example.com/foo","Test":"TestA","Output":"    foo_test.go:5: hello\n"}`,
+		`{"Time":"2026-10-18T16:16:30.703900000Z","Action":"output","Package":"example.com/foo","Test":"TestA","Output":"--- PASS: TestA (0.25s)\n"}`,
+		`{"Time":"2026-10-18T16:16:30.953698604Z","Action":"pass","Package":"example.com/foo","Test":"TestA","Elapsed":0.25}`,
+		`{"Action":"pass","Package":"example.com/foo","Elapsed":0.3}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	ConvertGoTest(strings.NewReader(jsonEvents), &buf, true)
+
+	out := buf.String()
+	want := "    ok 1 - TestA\n" +
+		"      ---\n" +
+		"      elapsed: 0.250\n" +
+		"      ended: 2026-10-18T16:16:30.953698604Z\n" +
+		"      output: 'foo_test.go:5: hello'\n" +
+		"      started: 2026-10-18T16:16:30.703698604Z\n" +
+		"      ...\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected %q in output, got:\n%s", want, out)
+	}
+
+	buf.Reset()
+	ConvertGoTest(strings.NewReader(jsonEvents), &buf, false)
+	if strings.Contains(buf.String(), "---") {
+		t.Errorf("expected no YAML without verbose, got:\n%s", buf.String())
+	}
+}
+
+func TestConvertFailureLocations(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/m/p","Test":"TestA"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"=== RUN   TestA\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"    a_test.go:9: got 1, want 2\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"    helper_test.go:20: mismatch:\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"        left: 3\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"        right: 4\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"    a_test.go:11: also wrong\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}`,
+		`{"Action":"fail","Package":"example.com/m/p","Test":"TestA","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/m/p","Elapsed":0.01}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{
+		ResolveFile: func(pkg, file string) string {
+			if pkg != "example.com/m/p" {
+				t.Errorf("unexpected package %q", pkg)
+			}
+			return "mod/p/" + file
+		},
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	want := "" +
+		"      file: mod/p/a_test.go\n" +
+		"      line: 9\n" +
+		"      locations:\n" +
+		"        - file: mod/p/a_test.go\n" +
+		"          line: 9\n" +
+		"          message: got 1, want 2\n" +
+		"        - file: mod/p/helper_test.go\n" +
+		"          line: 20\n" +
+		"          message: |\n" +
+		"            mismatch:\n" +
+		"            left: 3\n" +
+		"            right: 4\n" +
+		"        - file: mod/p/a_test.go\n" +
+		"          line: 11\n" +
+		"          message: also wrong\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected %q in output, got:\n%s", want, out)
+	}
+}
+
+func TestConvertFailureLocationsWithPanic(t *testing.T) {
+	jsonEvents := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/m/p","Test":"TestA"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"=== RUN   TestA\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"    a_test.go:9: got 1, want 2\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"panic: boom [recovered]\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"goroutine 7 [running]:\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"panic({0x6b6ea0?, 0x6ef0c0?})\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"\t/usr/local/go/src/runtime/panic.go:859 +0x125\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"example.com/m/p.TestA(0x2bd16fe4c6c8?)\n"}`,
+		`{"Action":"output","Package":"example.com/m/p","Test":"TestA","Output":"\t/src/m/p/a_test.go:10 +0x28\n"}`,
+		`{"Action":"fail","Package":"example.com/m/p","Test":"TestA","Elapsed":0}`,
+		`{"Action":"fail","Package":"example.com/m/p","Elapsed":0.01}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	ConvertGoTestWithOptions(strings.NewReader(jsonEvents), &buf, GoTestOptions{})
+
+	out := buf.String()
+	want := "" +
+		"      file: a_test.go\n" +
+		"      frames: |\n"
+	if !strings.Contains(out, want) || !strings.Contains(out, "      line: 9\n") {
+		t.Errorf("expected the Errorf location as the test's, got:\n%s", out)
+	}
+	want = "" +
+		"        - file: /src/m/p/a_test.go\n" +
+		"          line: 10\n" +
+		"          message: 'panic: boom'\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected the panic site among the locations, got:\n%s", out)
+	}
+}
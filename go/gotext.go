@@ -0,0 +1,252 @@
+package tap
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// === RUN   TestFoo/bar
+	textFrameRe = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+	//     --- FAIL: TestFoo/bar (0.25s)
+	textResultRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \((\d+(?:\.\d+)?)s\)`)
+	// ok  	example.com/foo	0.004s
+	// FAIL	example.com/foo [build failed]
+	// ?   	example.com/bar	[no test files]
+	textSummaryRe = regexp.MustCompile(`^(ok  |FAIL|\?   )\t(\S+)(?:\s+(.*))?$`)
+	// With -cover, a package without test files:
+	// 	example.com/bar		coverage: 0.0% of statements
+	textCoverOnlyRe = regexp.MustCompile(`^\t(\S+)\t\t(.*)$`)
+)
+
+// ConvertGoTestText reads the plain text output of go test -v, or of a test
+// binary run with -test.v, and writes TAP-14 to w as
+// ConvertGoTestWithOptions does for go test -json. Lines are attributed to
+// tests as test2json would. Output that lacks go test's per-package summary
+// lines, such as a test binary's, is reported as package pkg.
+// Returns an exit code: 0 for all pass, 1 for any failure, 2 for build errors.
+func ConvertGoTestText(r io.Reader, w io.Writer, pkg string, opts GoTestOptions) int {
+	c := newGoTestConverter(w, opts)
+	p := &goTextParser{emit: c.handle, pkg: pkg}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		p.line(scanner.Text())
+	}
+	p.end()
+	return c.finish()
+}
+
+// goTextParser turns go test -v text into the events go test -json would
+// have written. A package's events are held until its summary line names
+// the package.
+type goTextParser struct {
+	emit func(testEvent)
+	pkg  string
+
+	events []testEvent
+	// running lists the tests started and not yet finished; current is
+	// the one a === line last named, and reported the one whose result
+	// line came last, which indented lines after it belong to.
+	running  []string
+	current  string
+	reported string
+	// status is the bare PASS or FAIL line a test binary ends with.
+	status string
+	// topLevel is the last top-level test to report a result, and crashed
+	// the test a panic after it is attributed to.
+	topLevel string
+	crashed  string
+	// summarized is set once a package summary line has been read.
+	summarized bool
+
+	// build is the import path of the "# pkg" compiler output being read.
+	build  string
+	builds []string
+}
+
+func (p *goTextParser) line(line string) {
+	// -test.v=test2json marks framing lines with ^V.
+	line = strings.TrimPrefix(strings.TrimRight(line, "\r"), "\x16")
+	out := line + "\n"
+
+	if m := textFrameRe.FindStringSubmatch(line); m != nil {
+		p.build = ""
+		p.reported = ""
+		name := m[2]
+		if m[1] == "RUN" {
+			p.events = append(p.events, testEvent{Action: "run", Test: name})
+			p.running = append(p.running, name)
+		}
+		if m[1] != "PAUSE" {
+			p.current = name
+		}
+		p.output(name, out)
+		return
+	}
+
+	if m := textResultRe.FindStringSubmatch(line); m != nil {
+		name := m[2]
+		elapsed, _ := strconv.ParseFloat(m[3], 64)
+		p.output(name, out)
+		p.events = append(p.events, testEvent{Action: strings.ToLower(m[1]), Test: name, Elapsed: elapsed})
+		for i, r := range p.running {
+			if r == name {
+				p.running = append(p.running[:i], p.running[i+1:]...)
+				break
+			}
+		}
+		if p.current == name {
+			p.current = ""
+		}
+		p.reported = name
+		if !strings.Contains(name, "/") {
+			p.topLevel = name
+		}
+		return
+	}
+
+	if m := textSummaryRe.FindStringSubmatch(line); m != nil {
+		p.build = ""
+		p.output("", out)
+		p.summary(m[1], m[2], m[3])
+		return
+	}
+
+	if m := textCoverOnlyRe.FindStringSubmatch(line); m != nil && len(p.running) == 0 {
+		p.build = ""
+		p.output("", out)
+		p.summary("?   ", m[1], m[2])
+		return
+	}
+
+	if len(p.running) == 0 && p.crashed == "" {
+		if path, ok := strings.CutPrefix(line, "# "); ok {
+			p.build = path
+			p.builds = append(p.builds, path)
+		}
+		if p.build != "" {
+			p.emit(testEvent{Action: "build-output", ImportPath: p.build, Output: out})
+			return
+		}
+	}
+
+	indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+	// A panic once every test has reported, e.g. a parent's after its
+	// subtest failed, ends the binary; test2json gives it and the stack
+	// trace to the last test.
+	if len(p.running) == 0 && p.crashed == "" && p.topLevel != "" &&
+		(strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")) {
+		p.crashed = p.topLevel
+	}
+	var test string
+	switch {
+	case p.crashed != "":
+		test = p.crashed
+	case p.reported != "" && indented:
+		test = p.reported
+	case p.current != "":
+		test = p.current
+	case len(p.running) > 0:
+		test = p.running[len(p.running)-1]
+	}
+	if !indented {
+		p.reported = ""
+	}
+	if test == "" && (line == "PASS" || line == "FAIL") {
+		p.status = line
+	}
+	p.output(test, out)
+}
+
+func (p *goTextParser) output(test, out string) {
+	p.events = append(p.events, testEvent{Action: "output", Test: test, Output: out})
+}
+
+// summary ends a package at its go test summary line.
+func (p *goTextParser) summary(result, pkg, rest string) {
+	p.summarized = true
+	ev := testEvent{Action: "pass", Package: pkg}
+	switch result {
+	case "FAIL":
+		ev.Action = "fail"
+		if isBuildFailure(rest) {
+			for _, b := range p.builds {
+				if b == pkg || strings.HasPrefix(b, pkg+" ") {
+					ev.FailedBuild = b
+				}
+			}
+		}
+	case "?   ":
+		ev.Action = "skip"
+	}
+	if f := strings.Fields(rest); len(f) > 0 {
+		if d, ok := strings.CutSuffix(f[0], "s"); ok {
+			ev.Elapsed, _ = strconv.ParseFloat(d, 64)
+		}
+	}
+	p.flush(pkg, &ev)
+}
+
+// end reports what remains when the output ends without a summary line, as
+// a test binary's does, under the default package name.
+func (p *goTextParser) end() {
+	if len(p.events) == 0 && p.status == "" {
+		return
+	}
+	// After go test's summary lines only its overall PASS or FAIL is left.
+	if p.summarized {
+		hasTests := false
+		for _, ev := range p.events {
+			hasTests = hasTests || ev.Test != ""
+		}
+		if !hasTests {
+			return
+		}
+	}
+	var ev *testEvent
+	switch p.status {
+	case "PASS":
+		ev = &testEvent{Action: "pass", Package: p.pkg}
+	case "FAIL":
+		ev = &testEvent{Action: "fail", Package: p.pkg}
+	}
+	p.flush(p.pkg, ev)
+}
+
+// flush emits a package's held events, then its own result if any. Tests'
+// results come after their subtests', as go test -json orders them, since
+// without -v a parent's result is printed first.
+func (p *goTextParser) flush(pkg string, result *testEvent) {
+	var results []testEvent
+	for _, ev := range p.events {
+		ev.Package = pkg
+		if ev.Action == "pass" || ev.Action == "fail" || ev.Action == "skip" {
+			results = append(results, ev)
+			continue
+		}
+		p.emit(ev)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return strings.Count(results[i].Test, "/") > strings.Count(results[j].Test, "/")
+	})
+	for _, ev := range results {
+		p.emit(ev)
+	}
+	if result != nil {
+		p.emit(*result)
+	}
+
+	p.events = nil
+	p.running = nil
+	p.current = ""
+	p.reported = ""
+	p.status = ""
+	p.topLevel = ""
+	p.crashed = ""
+}
@@ -0,0 +1,180 @@
+package tap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertGoTestTextVerbose(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestF",
+		"--- PASS: TestF (0.00s)",
+		"PASS",
+		"coverage: 66.7% of statements",
+		"ok  \texample.com/cv/a\t0.005s\tcoverage: 66.7% of statements",
+		"=== RUN   TestG",
+		"    b_test.go:5: hello",
+		"=== RUN   TestG/s",
+		"--- PASS: TestG (0.00s)",
+		"    --- PASS: TestG/s (0.00s)",
+		"PASS",
+		"ok  \texample.com/cv/b\t0.005s",
+		"?   \texample.com/cv/c\t[no test files]",
+		"\texample.com/cv/d\t\tcoverage: 0.0% of statements",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestText(strings.NewReader(output), &buf, "unused", GoTestOptions{})
+	if exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    ok 1 - TestF\n",
+		"ok 1 - example.com/cv/a\n",
+		"coverage: 66.7",
+		"        ok 1 - s\n",
+		"    ok 1 - TestG\n",
+		"ok 2 - example.com/cv/b\n",
+		"ok 3 - example.com/cv/c # SKIP no test files\n",
+		"ok 4 - example.com/cv/d # SKIP no test files\n",
+		"1..4\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "unused") {
+		t.Errorf("expected no package named after the default, got:\n%s", out)
+	}
+}
+
+func TestConvertGoTestTextQuiet(t *testing.T) {
+	output := strings.Join([]string{
+		"--- FAIL: TestA (0.00s)",
+		"    --- FAIL: TestA/sub (0.00s)",
+		"        a_test.go:9: got 1, want 2",
+		"FAIL",
+		"FAIL\texample.com/q\t0.004s",
+		"ok  \texample.com/q/r\t(cached)",
+		"FAIL",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestText(strings.NewReader(output), &buf, "unused", GoTestOptions{})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"        not ok 1 - sub\n",
+		"file: a_test.go",
+		"line: 9",
+		"    not ok 1 - TestA\n",
+		"not ok 1 - example.com/q\n",
+		"ok 2 - example.com/q/r\n",
+		"cached: true",
+		"1..2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertGoTestTextBuildFailure(t *testing.T) {
+	output := strings.Join([]string{
+		"# example.com/pnb [example.com/pnb.test]",
+		`./a_test.go:5:2: "time" imported and not used`,
+		"FAIL\texample.com/pnb [build failed]",
+		"FAIL",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestText(strings.NewReader(output), &buf, "unused", GoTestOptions{})
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"not ok 1 - example.com/pnb\n",
+		`"time" imported and not used`,
+		"1..1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertGoTestTextPanicAfterResults(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestOK",
+		"--- PASS: TestOK (0.00s)",
+		"=== RUN   TestPanic",
+		"=== RUN   TestPanic/inner",
+		"--- FAIL: TestPanic (0.00s)",
+		"    --- FAIL: TestPanic/inner (0.00s)",
+		"panic: assignment to entry in nil map [recovered, repanicked]",
+		"",
+		"goroutine 8 [running]:",
+		"example.com/pn.TestPanic.func1(0x23b2682e06c8?)",
+		"\t/tmp/pn/p_test.go:14 +0x28",
+		"FAIL\texample.com/pn\t0.011s",
+		"FAIL",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestText(strings.NewReader(output), &buf, "unused", GoTestOptions{})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    ok 1 - TestOK\n",
+		"    not ok 2 - TestPanic\n",
+		"panic: assignment to entry in nil map",
+		"file: /tmp/pn/p_test.go",
+		"not ok 1 - example.com/pn\n",
+		"1..1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConvertGoTestTextTestBinary(t *testing.T) {
+	output := strings.Join([]string{
+		"=== RUN   TestG",
+		"    b_test.go:5: hello",
+		"=== RUN   TestG/s",
+		"--- PASS: TestG (0.00s)",
+		"    --- PASS: TestG/s (0.00s)",
+		"PASS",
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertGoTestText(strings.NewReader(output), &buf, "b.test", GoTestOptions{Verbose: true})
+	if exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"        ok 1 - s\n",
+		"    ok 1 - TestG\n",
+		"b_test.go:5: hello",
+		"ok 1 - b.test\n",
+		"1..1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package tap
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReportInput is one named TAP stream to include in an HTML report.
+type ReportInput struct {
+	Name   string
+	Reader *Reader
+}
+
+type htmlField struct {
+	Key   string
+	Value string
+}
+
+type htmlNode struct {
+	Name     string
+	Status   string // pass, fail, skip, todo, bail
+	Reason   string
+	Duration string
+	Bar      float64
+	Fields   []htmlField
+	Children []*htmlNode
+
+	elapsed float64
+	timed   bool
+}
+
+type htmlStream struct {
+	Name        string
+	Counts      testCounts
+	Failed      bool
+	Nodes       []*htmlNode
+	Diagnostics []Diagnostic
+}
+
+type htmlReport struct {
+	Title   string
+	Counts  testCounts
+	Streams []*htmlStream
+}
+
+// WriteHTML reads each TAP stream and writes a single self-contained HTML
+// report to w. Every stream becomes a section holding its collapsible
+// subtest tree, with failing tests ordered first and opened, their YAML
+// diagnostics rendered beneath them, and a bar per test scaled to the
+// slowest test in the report. Counts are of leaf test points.
+func WriteHTML(w io.Writer, title string, inputs ...ReportInput) error {
+	report := htmlReport{Title: title}
+	var maxElapsed float64
+
+	for _, in := range inputs {
+		root := buildTapTree(in.Reader, nil)
+		stream := &htmlStream{Name: in.Name}
+		for _, child := range root.children {
+			node, counts := newHTMLNode(child)
+			stream.Nodes = append(stream.Nodes, node)
+			stream.Counts.add(counts)
+			maxElapsed = max(maxElapsed, maxHTMLElapsed(node))
+		}
+		sortHTMLNodes(stream.Nodes)
+		for _, d := range in.Reader.Diagnostics() {
+			if d.Severity == SeverityError {
+				stream.Diagnostics = append(stream.Diagnostics, d)
+			}
+		}
+		stream.Failed = stream.Counts.Failed > 0 || len(stream.Diagnostics) > 0
+		report.Counts.add(stream.Counts)
+		report.Streams = append(report.Streams, stream)
+	}
+
+	if maxElapsed > 0 {
+		for _, s := range report.Streams {
+			for _, n := range s.Nodes {
+				scaleHTMLBars(n, maxElapsed)
+			}
+		}
+	}
+
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// newHTMLNode converts a test tree node and returns the counts of the leaf
+// test points beneath it.
+func newHTMLNode(n *tapNode) (*htmlNode, testCounts) {
+	node := &htmlNode{Name: n.name}
+	var counts testCounts
+
+	switch {
+	case n.bailOut != "" || (n.point == nil && len(n.children) == 0):
+		node.Status = "bail"
+		node.Name = "Bail out!"
+		node.Reason = n.bailOut
+	case n.point == nil:
+		// A subtest that never got its closing test point.
+		node.Status = "fail"
+		node.Reason = "unfinished"
+	case n.point.Directive == DirectiveSkip:
+		node.Status = "skip"
+		node.Reason = n.point.Reason
+	case n.point.Directive == DirectiveTodo:
+		node.Status = "todo"
+		node.Reason = n.point.Reason
+	case !n.point.OK:
+		node.Status = "fail"
+	default:
+		node.Status = "pass"
+	}
+
+	if elapsed, ok := yamlDuration(n.yaml); ok {
+		node.elapsed = elapsed
+		node.timed = true
+		node.Duration = fmt.Sprintf("%.3fs", elapsed)
+	}
+
+	keys := make([]string, 0, len(n.yaml))
+	for k := range n.yaml {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		node.Fields = append(node.Fields, htmlField{Key: k, Value: n.yaml[k]})
+	}
+
+	for _, child := range n.children {
+		c, cc := newHTMLNode(child)
+		node.Children = append(node.Children, c)
+		counts.add(cc)
+		if c.Status == "fail" || c.Status == "bail" {
+			// A subtest can close ok even though something inside it
+			// failed; the tree should still lead to the failure.
+			if node.Status == "pass" {
+				node.Status = "fail"
+			}
+		}
+	}
+	sortHTMLNodes(node.Children)
+
+	if len(n.children) == 0 {
+		counts.Total++
+		switch node.Status {
+		case "pass":
+			counts.Passed++
+		case "skip":
+			counts.Skipped++
+		case "todo":
+			counts.Todo++
+		default:
+			counts.Failed++
+		}
+	}
+	return node, counts
+}
+
+// sortHTMLNodes moves failures and bail outs to the front, otherwise
+// keeping the order of the stream.
+func sortHTMLNodes(nodes []*htmlNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].failing() && !nodes[j].failing()
+	})
+}
+
+func (n *htmlNode) failing() bool {
+	return n.Status == "fail" || n.Status == "bail"
+}
+
+func maxHTMLElapsed(n *htmlNode) float64 {
+	m := n.elapsed
+	for _, c := range n.Children {
+		m = max(m, maxHTMLElapsed(c))
+	}
+	return m
+}
+
+func scaleHTMLBars(n *htmlNode, maxElapsed float64) {
+	if n.timed {
+		n.Bar = 100 * n.elapsed / maxElapsed
+	}
+	for _, c := range n.Children {
+		scaleHTMLBars(c, maxElapsed)
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"multiline": func(s string) bool { return strings.Contains(s, "\n") },
+	"barWidth":  func(f float64) template.CSS { return template.CSS(fmt.Sprintf("width:%.1f%%", f)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; margin: 0 0 .5em; }
+.counts span { display: inline-block; margin-right: 1em; }
+.counts .fail { color: #b00020; font-weight: bold; }
+#search { width: 100%; max-width: 30em; padding: .4em; margin: 1em 0; font: inherit; }
+details { margin-left: 1.2em; }
+details.stream { margin: 1em 0; border-top: 1px solid #ddd; padding-top: .5em; }
+summary { cursor: pointer; list-style-position: outside; }
+.leaf { margin-left: 2.4em; }
+.row { display: flex; align-items: center; gap: .6em; padding: .1em 0; }
+.name { flex: 1; overflow-wrap: anywhere; }
+.status { font-size: .75em; font-weight: bold; padding: .1em .4em; border-radius: 3px; color: #fff; min-width: 3em; text-align: center; }
+.status.pass { background: #2e7d32; }
+.status.fail, .status.bail { background: #b00020; }
+.status.skip { background: #777; }
+.status.todo { background: #b26a00; }
+.reason { color: #666; font-style: italic; }
+.duration { color: #666; font-variant-numeric: tabular-nums; min-width: 5em; text-align: right; }
+.bar { width: 8em; height: .6em; background: #eee; }
+.bar div { height: 100%; background: #5c6bc0; }
+table.yaml { border-collapse: collapse; margin: .3em 0 .6em 2.4em; }
+table.yaml th { text-align: left; vertical-align: top; padding-right: 1em; color: #555; font-weight: normal; }
+table.yaml pre { margin: 0; white-space: pre-wrap; font-size: .9em; }
+.diagnostics { color: #b00020; margin-left: 1.2em; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="counts">{{template "counts" .Counts}}</div>
+<input id="search" type="search" placeholder="Filter tests by name">
+{{range .Streams}}
+<details class="stream searchable" open>
+<summary><strong class="name">{{.Name}}</strong> <span class="counts">{{template "counts" .Counts}}</span></summary>
+{{if .Diagnostics}}<ul class="diagnostics">{{range .Diagnostics}}<li>line {{.Line}}: [{{.Rule}}] {{.Message}}</li>{{end}}</ul>{{end}}
+{{range .Nodes}}{{template "node" .}}{{end}}
+</details>
+{{end}}
+<script>
+(function () {
+  var search = document.getElementById("search");
+  search.addEventListener("input", function () {
+    var q = search.value.toLowerCase();
+    var items = document.querySelectorAll(".test");
+    for (var i = items.length - 1; i >= 0; i--) {
+      var el = items[i];
+      var name = el.getAttribute("data-name").toLowerCase();
+      var match = q === "" || name.indexOf(q) >= 0 || el.querySelector(".test:not(.hidden)") !== null;
+      el.classList.toggle("hidden", !match);
+      if (q !== "" && match && el.tagName === "DETAILS") {
+        el.open = true;
+      }
+    }
+  });
+})();
+</script>
+</body>
+</html>
+{{define "counts"}}<span>{{.Total}} tests</span><span>{{.Passed}} passed</span><span{{if .Failed}} class="fail"{{end}}>{{.Failed}} failed</span><span>{{.Skipped}} skipped</span><span>{{.Todo}} todo</span>{{end}}
+{{define "row"}}<span class="row"><span class="status {{.Status}}">{{.Status}}</span><span class="name">{{.Name}}{{if .Reason}} <span class="reason">{{.Reason}}</span>{{end}}</span><span class="duration">{{.Duration}}</span><span class="bar">{{if .Duration}}<div style="{{barWidth .Bar}}"></div>{{end}}</span></span>{{end}}
+{{define "fields"}}{{if .}}<table class="yaml">{{range .}}<tr><th>{{.Key}}</th><td>{{if multiline .Value}}<pre>{{.Value}}</pre>{{else}}{{.Value}}{{end}}</td></tr>{{end}}</table>{{end}}{{end}}
+{{define "node"}}{{if .Children}}<details class="test"{{if or (eq .Status "fail") (eq .Status "bail")}} open{{end}} data-name="{{.Name}}"><summary>{{template "row" .}}</summary>
+{{template "fields" .Fields}}{{range .Children}}{{template "node" .}}{{end}}</details>
+{{else}}<div class="test leaf" data-name="{{.Name}}">{{template "row" .}}{{template "fields" .Fields}}</div>
+{{end}}{{end}}`))
@@ -0,0 +1,92 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+func writeHTMLString(t *testing.T, inputs map[string]string) string {
+	t.Helper()
+	var ins []ReportInput
+	for name, tap := range inputs {
+		ins = append(ins, ReportInput{Name: name, Reader: NewReader(strings.NewReader(tap))})
+	}
+	var buf strings.Builder
+	if err := WriteHTML(&buf, "report", ins...); err != nil {
+		t.Fatalf("WriteHTML error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteHTMLFailuresFirst(t *testing.T) {
+	out := writeHTMLString(t, map[string]string{"a.tap": "TAP version 14\n1..3\nok 1 - first\nnot ok 2 - second\nok 3 - third # TODO later\n"})
+
+	second := strings.Index(out, `data-name="second"`)
+	first := strings.Index(out, `data-name="first"`)
+	if second < 0 || first < 0 || second > first {
+		t.Errorf("expected failing test before passing one:\n%s", out)
+	}
+	if !strings.Contains(out, "<span>3 tests</span><span>1 passed</span><span class=\"fail\">1 failed</span><span>0 skipped</span><span>1 todo</span>") {
+		t.Errorf("expected counts in output:\n%s", out)
+	}
+}
+
+func TestWriteHTMLSubtestTreeAndYAML(t *testing.T) {
+	input := `TAP version 14
+# Subtest: pkg
+    1..2
+    ok 1 - fast
+      ---
+      elapsed: 0.5
+      ...
+    not ok 2 - slow
+      ---
+      elapsed: 2
+      output: |
+        first line
+        <second>
+      ...
+not ok 1 - pkg
+1..1
+`
+	out := writeHTMLString(t, map[string]string{"pkg.tap": input})
+
+	if !strings.Contains(out, `<details class="test" open data-name="pkg">`) {
+		t.Errorf("expected failing subtest as open details:\n%s", out)
+	}
+	if !strings.Contains(out, "<pre>first line\n&lt;second&gt;</pre>") {
+		t.Errorf("expected escaped multi-line YAML in pre:\n%s", out)
+	}
+	if !strings.Contains(out, `style="width:100.0%"`) || !strings.Contains(out, `style="width:25.0%"`) {
+		t.Errorf("expected duration bars scaled to the slowest test:\n%s", out)
+	}
+}
+
+func TestWriteHTMLSelfContained(t *testing.T) {
+	out := writeHTMLString(t, map[string]string{"a.tap": "TAP version 14\n1..1\nok 1 - a\n"})
+
+	for _, ref := range []string{"<link", "src=", "http://", "https://"} {
+		if strings.Contains(out, ref) {
+			t.Errorf("expected no external assets, found %q", ref)
+		}
+	}
+	if !strings.Contains(out, `id="search"`) {
+		t.Error("expected a search box")
+	}
+}
+
+func TestWriteHTMLBailOut(t *testing.T) {
+	out := writeHTMLString(t, map[string]string{"a.tap": "TAP version 14\n1..2\nok 1 - a\nBail out! database down\n"})
+
+	if !strings.Contains(out, `<span class="status bail">bail</span><span class="name">Bail out! <span class="reason">database down</span>`) {
+		t.Errorf("expected bail out row:\n%s", out)
+	}
+}
+
+func TestWriteHTMLStreamDiagnostics(t *testing.T) {
+	out := writeHTMLString(t, map[string]string{"a.tap": "TAP version 14\n1..3\nok 1 - a\n"})
+
+	if !strings.Contains(out, "[plan-count-mismatch]") {
+		t.Errorf("expected stream error diagnostics:\n%s", out)
+	}
+}
@@ -0,0 +1,204 @@
+package tap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// junitSuite matches both <testsuites> and <testsuite>, which share enough
+// structure across Surefire, nextest, pytest and ctest reports to decode
+// with one type. Suites may nest.
+type junitSuite struct {
+	XMLName   xml.Name
+	Name      string       `xml:"name,attr"`
+	Time      string       `xml:"time,attr"`
+	Suites    []junitSuite `xml:"testsuite"`
+	Cases     []junitCase  `xml:"testcase"`
+	SystemOut string       `xml:"system-out"`
+	SystemErr string       `xml:"system-err"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	Skipped   *junitProblem  `xml:"skipped"`
+	SystemOut string         `xml:"system-out"`
+	SystemErr string         `xml:"system-err"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ConvertJUnit reads JUnit/xUnit XML reports and writes them to w as one
+// TAP-14 document. Each testsuite becomes a subtest, nested as in the
+// report, and each testcase a test point: <failure> and <error> become
+// not ok with their type, message and stack in YAML, and <skipped> becomes
+// SKIP. If systemOut is true, captured system-out and system-err are
+// attached to the test points they belong to.
+// Returns an exit code: 0 for all pass, 1 for any failure, 2 for reports
+// that cannot be parsed.
+func ConvertJUnit(w io.Writer, systemOut bool, reports ...io.Reader) int {
+	tw := NewWriter(w)
+	exitCode := 0
+
+	for _, r := range reports {
+		var root junitSuite
+		if err := xml.NewDecoder(r).Decode(&root); err != nil {
+			tw.BailOut(fmt.Sprintf("parsing JUnit report: %v", err))
+			return 2
+		}
+
+		// A <testsuites> wrapper only groups suites; a bare <testsuite>
+		// root is itself a top-level suite.
+		suites := []junitSuite{root}
+		if root.XMLName.Local == "testsuites" {
+			suites = root.Suites
+			for _, tc := range root.Cases {
+				if emitJUnitCase(tw, "", tc, systemOut) {
+					exitCode = 1
+				}
+			}
+		}
+
+		for _, suite := range suites {
+			if emitJUnitSuite(tw, suite, systemOut) {
+				exitCode = 1
+			}
+		}
+	}
+
+	tw.Plan()
+	return exitCode
+}
+
+func emitJUnitSuite(tw *Writer, suite junitSuite, systemOut bool) bool {
+	sub := tw.Subtest(suite.Name)
+	failed := false
+	for _, child := range suite.Suites {
+		failed = emitJUnitSuite(sub, child, systemOut) || failed
+	}
+	for _, tc := range suite.Cases {
+		failed = emitJUnitCase(sub, suite.Name, tc, systemOut) || failed
+	}
+	sub.Plan()
+
+	diag := make(map[string]string)
+	if systemOut {
+		addJUnitOutput(diag, suite.SystemOut, suite.SystemErr)
+	}
+	if failed {
+		tw.NotOk(suite.Name, diag)
+	} else {
+		tw.OkWithDiagnostics(suite.Name, diag)
+	}
+	return failed
+}
+
+// parseJUnitTime parses a time attribute in seconds. A comma is read as
+// the decimal comma some locale-aware emitters write, as in "0,012", only
+// when one to three digits follow it and there is no other separator;
+// grouped values such as "1,234.5" are rejected rather than guessed at.
+func parseJUnitTime(s string) (float64, error) {
+	if whole, frac, ok := strings.Cut(s, ","); ok {
+		if len(frac) < 1 || len(frac) > 3 || strings.Trim(frac, "0123456789") != "" || strings.Contains(whole, ".") {
+			return 0, fmt.Errorf("ambiguous time %q", s)
+		}
+		s = whole + "." + frac
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func emitJUnitCase(tw *Writer, suiteName string, tc junitCase, systemOut bool) bool {
+	// Tools that name each suite after its class repeat that class on
+	// every testcase; otherwise the classname disambiguates the test.
+	name := tc.Name
+	if tc.Classname != "" && tc.Classname != suiteName {
+		name = tc.Classname + "." + tc.Name
+	}
+
+	if tc.Skipped != nil {
+		reason := firstNonEmpty(tc.Skipped.Message, strings.TrimSpace(tc.Skipped.Text), "skipped")
+		tw.Skip(name, reason)
+		return false
+	}
+
+	diag := make(map[string]string)
+	if systemOut {
+		addJUnitOutput(diag, tc.SystemOut, tc.SystemErr)
+	}
+
+	problems := append(append([]junitProblem(nil), tc.Failures...), tc.Errors...)
+	if len(problems) == 0 {
+		tw.OkWithDiagnostics(name, diag)
+		return false
+	}
+
+	if elapsed, err := parseJUnitTime(tc.Time); err == nil {
+		diag["elapsed"] = fmt.Sprintf("%.3f", elapsed)
+	} else if tc.Time != "" {
+		tw.Comment(fmt.Sprintf("%s: ignoring time: %v", name, err))
+	}
+	if tc.Classname != "" {
+		diag["classname"] = tc.Classname
+	}
+	if tc.File != "" {
+		diag["file"] = tc.File
+	}
+	if tc.Line != "" {
+		diag["line"] = tc.Line
+	}
+
+	var types, messages, stacks []string
+	for i, p := range problems {
+		kind := "failure"
+		if i >= len(tc.Failures) {
+			kind = "error"
+		}
+		types = append(types, firstNonEmpty(p.Type, kind))
+		if p.Message != "" {
+			messages = append(messages, p.Message)
+		}
+		if stack := strings.TrimSpace(p.Text); stack != "" {
+			stacks = append(stacks, stack)
+		}
+	}
+	diag["type"] = strings.Join(types, ", ")
+	if len(messages) > 0 {
+		diag["message"] = strings.Join(messages, "\n")
+	}
+	if len(stacks) > 0 {
+		diag["stack"] = strings.Join(stacks, "\n\n")
+	}
+
+	tw.NotOk(name, diag)
+	return true
+}
+
+func addJUnitOutput(diag map[string]string, stdout, stderr string) {
+	if s := strings.TrimSpace(stdout); s != "" {
+		diag["stdout"] = s
+	}
+	if s := strings.TrimSpace(stderr); s != "" {
+		diag["stderr"] = s
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
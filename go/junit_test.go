@@ -0,0 +1,152 @@
+package tap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const surefireReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="com.example.MathTest" tests="3" failures="1" errors="0" skipped="1" time="0.052">
+  <testcase name="adds" classname="com.example.MathTest" time="0.001"/>
+  <testcase name="divides" classname="com.example.MathTest" time="0.012">
+    <failure message="expected:&lt;2&gt; but was:&lt;3&gt;" type="org.opentest4j.AssertionFailedError">org.opentest4j.AssertionFailedError: expected:&lt;2&gt; but was:&lt;3&gt;
+	at com.example.MathTest.divides(MathTest.java:21)
+</failure>
+    <system-out>dividing</system-out>
+  </testcase>
+  <testcase name="slow" classname="com.example.MathTest" time="0">
+    <skipped message="disabled on CI"/>
+  </testcase>
+</testsuite>
+`
+
+const nextestReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="nextest-run" tests="2" failures="0" errors="1">
+  <testsuite name="ct::integ" tests="2" errors="1">
+    <testcase name="integ_ok" classname="ct::integ" time="0.004"/>
+    <testcase name="integ_crash" classname="ct::integ" time="0.010">
+      <error type="SIGSEGV">process crashed</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+func TestConvertJUnitSuitesAndCases(t *testing.T) {
+	var buf bytes.Buffer
+	exitCode := ConvertJUnit(&buf, false, strings.NewReader(surefireReport), strings.NewReader(nextestReport))
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "    # Subtest: com.example.MathTest\n    ok 1 - adds\n") {
+		t.Errorf("expected bare testsuite root as subtest:\n%s", out)
+	}
+	if !strings.Contains(out, "      type: org.opentest4j.AssertionFailedError\n") {
+		t.Errorf("expected failure type:\n%s", out)
+	}
+	if !strings.Contains(out, "      message: expected:<2> but was:<3>\n") {
+		t.Errorf("expected failure message:\n%s", out)
+	}
+	if !strings.Contains(out, "      stack: |\n        org.opentest4j.AssertionFailedError") {
+		t.Errorf("expected stack trace:\n%s", out)
+	}
+	if strings.Contains(out, "dividing") {
+		t.Errorf("expected system-out omitted by default:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 3 - slow # SKIP disabled on CI") {
+		t.Errorf("expected skipped testcase:\n%s", out)
+	}
+	if !strings.Contains(out, "    # Subtest: ct::integ\n") {
+		t.Errorf("expected testsuites children as subtests:\n%s", out)
+	}
+	if !strings.Contains(out, "      type: SIGSEGV\n") {
+		t.Errorf("expected error type:\n%s", out)
+	}
+	if !strings.Contains(out, "not ok 2 - ct::integ\n") {
+		t.Errorf("expected failing second suite:\n%s", out)
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertJUnitNestedSuitesAndSystemOut(t *testing.T) {
+	report := `<testsuites>
+  <testsuite name="outer">
+    <testsuite name="inner">
+      <testcase name="a" classname="pkg.Inner"><system-out>hello</system-out></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+	var buf bytes.Buffer
+	exitCode := ConvertJUnit(&buf, true, strings.NewReader(report))
+
+	if exitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "        # Subtest: inner\n        ok 1 - pkg.Inner.a\n          ---\n          stdout: hello\n") {
+		t.Errorf("expected nested suite with system-out:\n%s", out)
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertJUnitMalformed(t *testing.T) {
+	var buf bytes.Buffer
+	exitCode := ConvertJUnit(&buf, false, strings.NewReader("<testsuite><testcase"))
+
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+	if !strings.Contains(buf.String(), "Bail out! parsing JUnit report") {
+		t.Errorf("expected bail out:\n%s", buf.String())
+	}
+}
+
+func TestParseJUnitTime(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want float64
+	}{
+		{"0.012", 0.012},
+		{"0,012", 0.012},
+		{"1,234", 1.234},
+		{"3", 3},
+	} {
+		got, err := parseJUnitTime(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("parseJUnitTime(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"1,234.5", "1.234,5", "1,2345", "1,", "1,2,3", "1,2e3"} {
+		if got, err := parseJUnitTime(in); err == nil {
+			t.Errorf("parseJUnitTime(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestConvertJUnitAmbiguousTime(t *testing.T) {
+	report := `<testsuite name="s"><testcase name="t" time="1,234.5"><failure message="boom"/></testcase></testsuite>`
+
+	var buf bytes.Buffer
+	ConvertJUnit(&buf, false, strings.NewReader(report))
+
+	out := buf.String()
+	if !strings.Contains(out, `# t: ignoring time: ambiguous time "1,234.5"`) {
+		t.Errorf("expected a comment about the time, got:\n%s", out)
+	}
+	if strings.Contains(out, "elapsed:") {
+		t.Errorf("expected no elapsed for an ambiguous time, got:\n%s", out)
+	}
+}
@@ -0,0 +1,146 @@
+package tap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarkdownOptions configures WriteMarkdown.
+type MarkdownOptions struct {
+	// MaxBytes limits the size of the report, e.g. for PR comments. Failure
+	// and skip entries that do not fit are replaced by a count of what was
+	// left out; a limit too small for the verdict and totals is an error.
+	// Zero means no limit.
+	MaxBytes int
+}
+
+// Each failure message in a Markdown report is cut to this many lines and
+// bytes, so that one noisy test cannot crowd out the rest.
+const (
+	markdownMessageLines = 40
+	markdownMessageBytes = 4000
+)
+
+// WriteMarkdown reads a TAP stream and writes a compact Markdown report to
+// w: the verdict, a totals table, a collapsible section per failing test
+// with its YAML message, the skipped tests and TODOs with their reasons,
+// and any protocol diagnostics.
+func WriteMarkdown(r *Reader, w io.Writer, opts MarkdownOptions) error {
+	root := buildTapTree(r, nil)
+
+	var results testResults
+	for _, node := range root.children {
+		results.collect(node, "")
+	}
+	return writeMarkdownSummary(w, &results, r.Verdict(), r.Diagnostics(), opts.MaxBytes)
+}
+
+func writeMarkdownSummary(w io.Writer, results *testResults, verdict Verdict, diags []Diagnostic, maxBytes int) error {
+	var b strings.Builder
+
+	status := "✅ passed"
+	if !verdict.Passed {
+		reasons := make([]string, len(verdict.Reasons))
+		for i, r := range verdict.Reasons {
+			reasons[i] = r.String()
+		}
+		status = "❌ failed (" + strings.Join(reasons, ", ") + ")"
+	}
+	counts := results.counts
+	fmt.Fprintf(&b, "### TAP results: %s\n\n", status)
+	fmt.Fprintf(&b, "| Total | Passed | Failed | Skipped | Todo |\n")
+	fmt.Fprintf(&b, "| ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d |\n",
+		counts.Total, counts.Passed, counts.Failed, counts.Skipped, counts.Todo)
+
+	// Sections are added whole while they fit, keeping room for the note
+	// saying how much was left out.
+	const noteReserve = 120
+	omitted := 0
+	add := func(s string, entry bool) {
+		if omitted > 0 || (maxBytes > 0 && b.Len()+len(s)+noteReserve > maxBytes) {
+			if entry {
+				omitted++
+			}
+			return
+		}
+		b.WriteString(s)
+	}
+
+	if len(results.failures) > 0 {
+		add("\n#### Failures\n", false)
+		for _, f := range results.failures {
+			location := ""
+			if f.file != "" {
+				location = " (" + f.file
+				if f.line != "" {
+					location += ":" + f.line
+				}
+				location += ")"
+			}
+			add(fmt.Sprintf("\n<details><summary><code>%s</code>%s</summary>\n\n```\n%s\n```\n\n</details>\n",
+				escapeMarkdownHTML(f.name), escapeMarkdownHTML(location), truncateMarkdownMessage(f.message)), true)
+		}
+	}
+
+	if len(results.skipped) > 0 {
+		add("\n#### Skipped and TODO\n\n", false)
+		for _, s := range results.skipped {
+			entry := fmt.Sprintf("- `%s` %s", strings.ReplaceAll(s.name, "`", "'"), s.directive)
+			if s.reason != "" {
+				entry += ": " + escapeMarkdownInline(s.reason)
+			}
+			add(entry+"\n", true)
+		}
+	}
+
+	if len(diags) > 0 {
+		add("\n#### TAP diagnostics\n\n", false)
+		for _, d := range diags {
+			add(fmt.Sprintf("- line %d: %s: [%s] %s\n", d.Line, d.Severity, d.Rule, d.Message), true)
+		}
+	}
+
+	if omitted > 0 {
+		fmt.Fprintf(&b, "\n_Report truncated: %d more entries omitted._\n", omitted)
+	}
+	if maxBytes > 0 && b.Len() > maxBytes {
+		return fmt.Errorf("a %d-byte limit leaves no room for the verdict and totals", maxBytes)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// truncateMarkdownMessage cuts a failure message to the per-message limits
+// and makes it safe to place inside a fenced code block.
+func truncateMarkdownMessage(message string) string {
+	lines := strings.Split(message, "\n")
+	truncated := false
+	if len(lines) > markdownMessageLines {
+		lines = lines[:markdownMessageLines]
+		truncated = true
+	}
+	message = strings.Join(lines, "\n")
+	if len(message) > markdownMessageBytes {
+		message = strings.ToValidUTF8(message[:markdownMessageBytes], "")
+		truncated = true
+	}
+	if truncated {
+		message += "\n… (truncated)"
+	}
+	return strings.ReplaceAll(message, "```", "` ` `")
+}
+
+func escapeMarkdownHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// escapeMarkdownInline escapes text for a single line of Markdown, such as
+// a list item or table cell: HTML as for test names, plus pipes and line
+// breaks.
+func escapeMarkdownInline(s string) string {
+	s = strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+	return strings.ReplaceAll(escapeMarkdownHTML(s), "|", "\\|")
+}
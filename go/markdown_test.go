@@ -0,0 +1,101 @@
+package tap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func writeMarkdownString(t *testing.T, input string, opts MarkdownOptions) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := WriteMarkdown(NewReader(strings.NewReader(input)), &buf, opts); err != nil {
+		t.Fatalf("WriteMarkdown error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	input := `TAP version 14
+# Subtest: pkg
+    1..4
+    ok 1 - TestA
+    not ok 2 - TestB
+      ---
+      message: |
+        got 1
+        want 2
+      ...
+    ok 3 - TestC # SKIP needs network
+    not ok 4 - TestD # TODO not written
+not ok 1 - pkg
+1..1
+`
+	out := writeMarkdownString(t, input, MarkdownOptions{})
+
+	for _, want := range []string{
+		"### TAP results: ❌ failed (tests-failed)",
+		"| 4 | 1 | 1 | 1 | 1 |",
+		"<details><summary><code>pkg/TestB</code></summary>\n\n```\ngot 1\nwant 2\n```",
+		"- `pkg/TestC` SKIP: needs network\n",
+		"- `pkg/TestD` TODO: not written\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMarkdownPassed(t *testing.T) {
+	out := writeMarkdownString(t, "TAP version 14\n1..1\nok 1 - a\n", MarkdownOptions{})
+
+	if !strings.Contains(out, "✅ passed") || strings.Contains(out, "Failures") {
+		t.Errorf("expected a passing report without failures:\n%s", out)
+	}
+}
+
+func TestWriteMarkdownMaxBytes(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("TAP version 14\n1..50\n")
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&b, "not ok %d - test %d\n  ---\n  message: %s\n  ...\n", i, i, strings.Repeat("x", 200))
+	}
+
+	out := writeMarkdownString(t, b.String(), MarkdownOptions{MaxBytes: 2000})
+	if len(out) > 2000 {
+		t.Errorf("expected report within 2000 bytes, got %d", len(out))
+	}
+	if !strings.Contains(out, "| 50 | 0 | 50 | 0 | 0 |") {
+		t.Errorf("expected totals to survive truncation:\n%s", out)
+	}
+	if !strings.Contains(out, "more entries omitted") {
+		t.Errorf("expected truncation note:\n%s", out)
+	}
+}
+
+func TestWriteMarkdownMaxBytesTooSmall(t *testing.T) {
+	var buf strings.Builder
+	err := WriteMarkdown(NewReader(strings.NewReader("TAP version 14\n1..1\nnot ok 1 - a\n")), &buf, MarkdownOptions{MaxBytes: 50})
+	if err == nil {
+		t.Errorf("expected an error for a limit below the header, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteMarkdownEscapesSkipReasons(t *testing.T) {
+	input := "TAP version 14\n1..1\nok 1 - a # SKIP a | b <c>\n"
+	out := writeMarkdownString(t, input, MarkdownOptions{})
+	if !strings.Contains(out, "- `a` SKIP: a \\| b &lt;c&gt;\n") {
+		t.Errorf("expected escaped skip reason:\n%s", out)
+	}
+}
+
+func TestTruncateMarkdownMessage(t *testing.T) {
+	long := strings.Repeat("line\n", markdownMessageLines+10)
+	got := truncateMarkdownMessage(long)
+	if strings.Count(got, "\n") != markdownMessageLines || !strings.HasSuffix(got, "… (truncated)") {
+		t.Errorf("expected message cut to %d lines, got:\n%s", markdownMessageLines, got)
+	}
+	if got := truncateMarkdownMessage("a ``` b"); strings.Contains(got, "```") {
+		t.Errorf("expected code fences to be broken up, got %q", got)
+	}
+}
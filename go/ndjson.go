@@ -0,0 +1,42 @@
+package tap
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ndjsonSummary is the record that ends an NDJSON stream.
+type ndjsonSummary struct {
+	Type        string       `json:"type"`
+	Summary     Summary      `json:"summary"`
+	Verdict     Verdict      `json:"verdict"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// WriteNDJSON streams the rest of the TAP input to w as newline-delimited
+// JSON: one object per Event, written as soon as the Reader produces it,
+// followed by a final record of type "summary" carrying the summary,
+// verdict and diagnostics.
+func (r *Reader) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+
+	diags := r.Diagnostics()
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return enc.Encode(ndjsonSummary{
+		Type:        "summary",
+		Summary:     r.Summary(),
+		Verdict:     r.Verdict(),
+		Diagnostics: diags,
+	})
+}
@@ -0,0 +1,57 @@
+package tap
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteNDJSONOneRecordPerEvent(t *testing.T) {
+	input := "TAP version 14\n1..2\nok 1 - a\nok 2 - b # SKIP later\n"
+	var buf strings.Builder
+	if err := NewReader(strings.NewReader(input)).WriteNDJSON(&buf); err != nil {
+		t.Fatalf("WriteNDJSON error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 4 events and a summary, got %d lines:\n%s", len(lines), buf.String())
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(lines[3]), &ev); err != nil {
+		t.Fatalf("event is not valid JSON: %v", err)
+	}
+	if ev.Type != EventTestPoint || ev.TestPoint.Directive != DirectiveSkip {
+		t.Errorf("expected SKIP test point to round-trip, got %+v", ev)
+	}
+	if !strings.Contains(lines[3], `"type":"test_point"`) || !strings.Contains(lines[3], `"directive":"SKIP"`) {
+		t.Errorf("expected stable string names, got %s", lines[3])
+	}
+
+	var last map[string]any
+	if err := json.Unmarshal([]byte(lines[4]), &last); err != nil {
+		t.Fatalf("summary is not valid JSON: %v", err)
+	}
+	if last["type"] != "summary" {
+		t.Errorf("expected summary record last, got %s", lines[4])
+	}
+	if _, ok := last["diagnostics"].([]any); !ok {
+		t.Errorf("expected diagnostics array, got %s", lines[4])
+	}
+}
+
+func TestWriteNDJSONSeverityString(t *testing.T) {
+	var buf strings.Builder
+	NewReader(strings.NewReader("TAP version 14\nok 1 - a\n")).WriteNDJSON(&buf)
+
+	scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+	var last string
+	for scanner.Scan() {
+		last = scanner.Text()
+	}
+	if !strings.Contains(last, `"severity":"error","rule":"plan-required"`) {
+		t.Errorf("expected severity as string, got %s", last)
+	}
+}
@@ -0,0 +1,318 @@
+package tap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrettyOptions configures WritePretty.
+type PrettyOptions struct {
+	// Dots prints one character per test instead of one line per test.
+	Dots bool
+	// Color uses ANSI colors, e.g. when writing to a terminal.
+	Color bool
+	// Live keeps a running tally on the last line of a terminal, rewritten
+	// as tests arrive. Only used in spec mode, and only with Color, as
+	// rewriting the line takes escape codes too.
+	Live bool
+}
+
+// Tests per row in dots mode.
+const prettyDotsPerRow = 50
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+	ansiGray   = "\033[90m"
+)
+
+type prettyPoint struct {
+	point  *TestPointResult
+	depth  int
+	yaml   map[string]string
+	closer bool
+}
+
+type prettyWriter struct {
+	w    io.Writer
+	opts PrettyOptions
+	err  error
+
+	counts   testCounts
+	failures []testFailure
+	yamls    [][]string
+	pending  *prettyPoint
+	status   bool // a live status line is showing
+	dots     int
+
+	// names[d] is the name of the subtest at depth d, from its
+	// "# Subtest:" comment; childSeen[d] records that the test point at
+	// depth d about to arrive closes a subtest; failedBefore[d] is the
+	// number of failures when that subtest began.
+	names        map[int]string
+	childSeen    map[int]bool
+	failedBefore map[int]int
+}
+
+// WritePretty reads a TAP stream and renders it for people as events
+// arrive. In spec mode each test is a line, indented beneath the subtests
+// that contain it; in dots mode each test is a character. Failures are
+// printed again at the end with their YAML diagnostics, followed by any
+// protocol diagnostics and a summary line.
+func WritePretty(r *Reader, w io.Writer, opts PrettyOptions) error {
+	pw := &prettyWriter{
+		w:            w,
+		opts:         opts,
+		names:        make(map[int]string),
+		childSeen:    make(map[int]bool),
+		failedBefore: make(map[int]int),
+	}
+
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+
+		if ev.Type == EventYAMLDiagnostic && pw.pending != nil {
+			pw.pending.yaml = ev.YAML
+			pw.flush()
+			continue
+		}
+		pw.flush()
+
+		// Whether this line opens a subtest at its depth, rather than
+		// continuing one already open.
+		opens := ev.Depth > 0 && !pw.childSeen[ev.Depth-1]
+		for d := 0; d < ev.Depth; d++ {
+			if !pw.childSeen[d] {
+				pw.childSeen[d] = true
+				pw.failedBefore[d] = len(pw.failures)
+			}
+		}
+
+		switch ev.Type {
+		case EventComment:
+			name, ok := strings.CutPrefix(ev.Comment, "Subtest:")
+			if !ok {
+				continue
+			}
+			// "# Subtest:" is written either indented with the subtest's
+			// own lines or at the parent's depth.
+			depth := ev.Depth
+			if !opens {
+				depth++
+			}
+			pw.names[depth] = strings.TrimSpace(name)
+			if !opts.Dots {
+				pw.line(depth-1, pw.paint(ansiBold, strings.TrimSpace(name)))
+			}
+		case EventTestPoint:
+			closer := pw.childSeen[ev.Depth]
+			pw.pending = &prettyPoint{point: ev.TestPoint, depth: ev.Depth, closer: closer}
+		case EventBailOut:
+			pw.failures = append(pw.failures, testFailure{name: "Bail out!", message: ev.BailOut.Reason})
+			pw.yamls = append(pw.yamls, nil)
+			if opts.Dots {
+				pw.endDots()
+			}
+			pw.line(ev.Depth, pw.paint(ansiRed+ansiBold, strings.TrimSpace("Bail out! "+ev.BailOut.Reason)))
+		}
+	}
+	pw.flush()
+	if opts.Dots {
+		pw.endDots()
+	}
+	pw.clearStatus()
+
+	pw.printFailures()
+	diags := r.Diagnostics()
+	if len(diags) > 0 {
+		pw.write("\n")
+		for _, d := range diags {
+			color := ansiYellow
+			if d.Severity == SeverityError {
+				color = ansiRed
+			}
+			pw.write(pw.paint(color, formatDiagnostic(d)))
+		}
+	}
+	pw.printSummary(r.Verdict())
+	return pw.err
+}
+
+// flush renders the pending test point now that its YAML block, if any,
+// has been read.
+func (pw *prettyWriter) flush() {
+	p := pw.pending
+	if p == nil {
+		return
+	}
+	pw.pending = nil
+
+	name := p.point.Description
+	if p.closer {
+		delete(pw.childSeen, p.depth)
+		if n := pw.names[p.depth+1]; n != "" {
+			name = n
+		}
+		delete(pw.names, p.depth+1)
+		if failedOnItsOwn(p.point, len(pw.failures) > pw.failedBefore[p.depth]) {
+			pw.addFailure(p, pw.path(p.depth, name))
+			if pw.opts.Dots {
+				pw.dot(pw.paint(ansiRed, "F"))
+			} else {
+				pw.line(p.depth, pw.paint(ansiRed, "✗ "+name))
+			}
+		}
+		return
+	}
+
+	var mark, color string
+	switch {
+	case p.point.Directive == DirectiveSkip:
+		pw.counts.Skipped++
+		mark, color = "s", ansiCyan
+	case p.point.Directive == DirectiveTodo:
+		pw.counts.Todo++
+		mark, color = "t", ansiYellow
+	case !p.point.OK:
+		pw.counts.Failed++
+		mark, color = "F", ansiRed
+		pw.addFailure(p, pw.path(p.depth, name))
+	default:
+		pw.counts.Passed++
+		mark, color = ".", ansiGreen
+	}
+	pw.counts.Total++
+
+	if pw.opts.Dots {
+		pw.dot(pw.paint(color, mark))
+		return
+	}
+
+	var text string
+	switch mark {
+	case "s":
+		text = pw.paint(color, "- "+name) + " " + pw.paint(ansiGray, strings.TrimSpace("# SKIP "+p.point.Reason))
+	case "t":
+		text = pw.paint(color, "~ "+name) + " " + pw.paint(ansiGray, strings.TrimSpace("# TODO "+p.point.Reason))
+	case "F":
+		text = pw.paint(color, "✗ "+name)
+	default:
+		text = pw.paint(color, "✓") + " " + name
+	}
+	if elapsed, ok := yamlDuration(p.yaml); ok {
+		text += pw.paint(ansiGray, fmt.Sprintf(" (%.3fs)", elapsed))
+	}
+	pw.line(p.depth, text)
+}
+
+func (pw *prettyWriter) addFailure(p *prettyPoint, name string) {
+	pw.failures = append(pw.failures, testFailure{name: name})
+	pw.yamls = append(pw.yamls, strings.Split(strings.TrimSuffix(formatYAMLBody(p.yaml), "\n"), "\n"))
+}
+
+// path names a test by the subtests containing it.
+func (pw *prettyWriter) path(depth int, name string) string {
+	var parts []string
+	for d := 1; d <= depth; d++ {
+		if n := pw.names[d]; n != "" {
+			parts = append(parts, n)
+		}
+	}
+	return strings.Join(append(parts, name), "/")
+}
+
+func (pw *prettyWriter) dot(mark string) {
+	pw.write(mark)
+	pw.dots++
+	if pw.dots%prettyDotsPerRow == 0 {
+		pw.write(fmt.Sprintf(" %d\n", pw.counts.Total))
+	}
+}
+
+func (pw *prettyWriter) endDots() {
+	if pw.dots%prettyDotsPerRow != 0 {
+		pw.write("\n")
+	}
+	pw.dots = 0
+}
+
+// line writes a line indented to depth, then redraws the live tally.
+func (pw *prettyWriter) line(depth int, text string) {
+	pw.clearStatus()
+	pw.write(strings.Repeat("  ", depth) + text + "\n")
+	if pw.opts.Live && pw.opts.Color && !pw.opts.Dots {
+		pw.write(pw.tally())
+		pw.status = true
+	}
+}
+
+func (pw *prettyWriter) clearStatus() {
+	if pw.status {
+		pw.write("\r\033[K")
+		pw.status = false
+	}
+}
+
+func (pw *prettyWriter) tally() string {
+	c := pw.counts
+	failed := fmt.Sprintf("%d failed", c.Failed)
+	if c.Failed > 0 {
+		failed = pw.paint(ansiRed, failed)
+	}
+	return fmt.Sprintf("%d passed, %s, %d skipped, %d todo", c.Passed, failed, c.Skipped, c.Todo)
+}
+
+func (pw *prettyWriter) printFailures() {
+	if len(pw.failures) == 0 {
+		return
+	}
+	pw.write("\n" + pw.paint(ansiBold, "Failures:") + "\n")
+	for i, f := range pw.failures {
+		pw.write(fmt.Sprintf("\n  %d) %s\n", i+1, pw.paint(ansiRed, f.name)))
+		if f.message != "" {
+			pw.write("     " + f.message + "\n")
+		}
+		for _, l := range pw.yamls[i] {
+			if l != "" {
+				pw.write("     " + l + "\n")
+			}
+		}
+	}
+}
+
+func (pw *prettyWriter) printSummary(v Verdict) {
+	c := pw.counts
+	text := fmt.Sprintf("%d tests: %s", c.Total, pw.tally())
+	if v.Passed {
+		text = pw.paint(ansiGreen+ansiBold, "PASS") + " " + text
+	} else {
+		reasons := make([]string, len(v.Reasons))
+		for i, r := range v.Reasons {
+			reasons[i] = r.String()
+		}
+		text = pw.paint(ansiRed+ansiBold, "FAIL") + " " + text + " (" + strings.Join(reasons, ", ") + ")"
+	}
+	pw.write("\n" + text + "\n")
+}
+
+func (pw *prettyWriter) paint(color, s string) string {
+	if !pw.opts.Color || s == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func (pw *prettyWriter) write(s string) {
+	if pw.err != nil {
+		return
+	}
+	_, pw.err = io.WriteString(pw.w, s)
+}
@@ -0,0 +1,98 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+const prettyInput = `TAP version 14
+# Subtest: pkg
+    1..3
+    ok 1 - TestA
+      ---
+      elapsed: 0.5
+      ...
+    not ok 2 - TestB
+      ---
+      message: got 1
+      ...
+    ok 3 - TestC # SKIP slow
+not ok 1 - pkg
+ok 2 - loose # TODO later
+1..2
+`
+
+func writePrettyString(t *testing.T, input string, opts PrettyOptions) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := WritePretty(NewReader(strings.NewReader(input)), &buf, opts); err != nil {
+		t.Fatalf("WritePretty error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWritePrettySpec(t *testing.T) {
+	got := writePrettyString(t, prettyInput, PrettyOptions{})
+
+	want := `pkg
+  ✓ TestA (0.500s)
+  ✗ TestB
+  - TestC # SKIP slow
+~ loose # TODO later
+
+Failures:
+
+  1) pkg/TestB
+     message: got 1
+
+FAIL 4 tests: 1 passed, 1 failed, 1 skipped, 1 todo (tests-failed)
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWritePrettyDots(t *testing.T) {
+	got := writePrettyString(t, prettyInput, PrettyOptions{Dots: true})
+
+	if !strings.HasPrefix(got, ".Fst\n") {
+		t.Errorf("expected a row of dots, got:\n%s", got)
+	}
+	if !strings.Contains(got, "1) pkg/TestB") {
+		t.Errorf("expected failure re-printed, got:\n%s", got)
+	}
+}
+
+func TestWritePrettyColor(t *testing.T) {
+	got := writePrettyString(t, "TAP version 14\n1..1\nok 1 - a\n", PrettyOptions{Color: true})
+
+	if !strings.Contains(got, ansiGreen+"✓"+ansiReset+" a") {
+		t.Errorf("expected colored pass mark, got %q", got)
+	}
+	if got := writePrettyString(t, "TAP version 14\n1..1\nok 1 - a\n", PrettyOptions{}); strings.Contains(got, "\033[") {
+		t.Errorf("expected no escape codes without color, got %q", got)
+	}
+}
+
+func TestWritePrettyLive(t *testing.T) {
+	input := "TAP version 14\n1..2\nok 1 - a\nok 2 - b\n"
+	got := writePrettyString(t, input, PrettyOptions{Live: true, Color: true})
+
+	if !strings.Contains(got, " a\n1 passed, 0 failed, 0 skipped, 0 todo\r\033[K\033[32m✓\033[0m b\n") {
+		t.Errorf("expected live tally rewritten between tests, got %q", got)
+	}
+
+	// Without color, as with --no-color, no escape codes are written.
+	if got := writePrettyString(t, input, PrettyOptions{Live: true}); strings.Contains(got, "\033[") {
+		t.Errorf("expected no escape codes without color, got %q", got)
+	}
+}
+
+func TestWritePrettyFailedSubtestWithoutFailingTests(t *testing.T) {
+	input := "TAP version 14\n    # Subtest: pkg\n    1..1\n    ok 1 - TestA\nnot ok 1 - pkg\n  ---\n  message: panic: boom\n  ...\n1..1\n"
+	got := writePrettyString(t, input, PrettyOptions{})
+
+	if !strings.Contains(got, "✗ pkg\n") || !strings.Contains(got, "1) pkg\n     message: panic: boom") {
+		t.Errorf("expected failed subtest reported, got:\n%s", got)
+	}
+}
@@ -0,0 +1,354 @@
+package tap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pytestReport is one line of pytest-reportlog output. Only TestReport,
+// CollectReport and SessionFinish lines are used.
+type pytestReport struct {
+	ReportType string          `json:"$report_type"`
+	NodeID     string          `json:"nodeid"`
+	Location   []any           `json:"location"`
+	Outcome    string          `json:"outcome"`
+	When       string          `json:"when"`
+	LongRepr   json.RawMessage `json:"longrepr"`
+	Sections   [][]string      `json:"sections"`
+	Duration   float64         `json:"duration"`
+	WasXFail   *string         `json:"wasxfail"`
+	// WorkerID names the pytest-xdist worker that ran the test, if any.
+	WorkerID string `json:"worker_id"`
+}
+
+type pytestLongRepr struct {
+	ReprCrash *struct {
+		Path    string `json:"path"`
+		LineNo  int    `json:"lineno"`
+		Message string `json:"message"`
+	} `json:"reprcrash"`
+	ReprTraceback *struct {
+		ReprEntries []struct {
+			Data struct {
+				Lines []string `json:"lines"`
+			} `json:"data"`
+		} `json:"reprentries"`
+	} `json:"reprtraceback"`
+}
+
+type pytestResult struct {
+	outcome  string // passed, failed, skipped
+	when     string // phase that determined the outcome
+	xfail    bool
+	reason   string
+	message  string
+	explain  string // the traceback's "E" lines
+	file     string
+	line     int
+	output   strings.Builder
+	sections map[string]bool
+	duration float64
+}
+
+type pytestNode struct {
+	name     string
+	children []*pytestNode
+	childMap map[string]*pytestNode
+	result   *pytestResult
+}
+
+func (n *pytestNode) child(name string) *pytestNode {
+	if n.childMap == nil {
+		n.childMap = make(map[string]*pytestNode)
+	}
+	c := n.childMap[name]
+	if c == nil {
+		c = &pytestNode{name: name}
+		n.childMap[name] = c
+		n.children = append(n.children, c)
+	}
+	return c
+}
+
+// pytestModule is a module whose reports are still arriving, along with
+// the workers that have reported for it.
+type pytestModule struct {
+	node    *pytestNode
+	workers map[string]bool
+}
+
+// ConvertPytest reads pytest-reportlog JSONL from r and writes TAP-14 to w.
+// Each test module becomes a subtest, emitted once every worker that ran
+// any of its tests has moved on to another module, or at the end of the
+// session. Reports interleaved across modules, as pytest-xdist writes
+// them, therefore still give one subtest per module, while a serial run
+// streams module by module. A report arriving for a module already
+// emitted starts another subtest of the same name.
+// Classes and parametrized cases nest as further subtests.
+// xfail and xpass map to TODO. If verbose is true, passing tests include
+// their captured output.
+// Returns an exit code: 0 for all pass, 1 for any failure, 2 for collection
+// errors.
+func ConvertPytest(r io.Reader, w io.Writer, verbose bool) int {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	tw := NewWriter(w)
+	exitCode := 0
+
+	var modules []*pytestModule
+	moduleMap := make(map[string]*pytestModule)
+	// latest is the module of each worker's latest report; a serial run
+	// has the single worker "".
+	latest := make(map[string]string)
+	flush := func(all bool) {
+		pending := modules[:0]
+		for _, module := range modules {
+			done := all
+			if !done {
+				done = true
+				for worker := range module.workers {
+					if latest[worker] == module.node.name {
+						done = false
+					}
+				}
+			}
+			if !done {
+				pending = append(pending, module)
+				continue
+			}
+			if emitPytestNode(tw, module.node, verbose) {
+				exitCode = max(exitCode, 1)
+			}
+			delete(moduleMap, module.node.name)
+		}
+		modules = pending
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		var rep pytestReport
+		if err := json.Unmarshal([]byte(line), &rep); err != nil {
+			tw.Comment(fmt.Sprintf("unparseable: %s", line))
+			continue
+		}
+
+		switch rep.ReportType {
+		case "CollectReport":
+			if rep.Outcome != "failed" {
+				continue
+			}
+			name := rep.NodeID
+			if name == "" {
+				name = "collection"
+			}
+			tw.NotOk(name, map[string]string{
+				"message": longReprText(rep.LongRepr),
+				"when":    "collect",
+			})
+			exitCode = 2
+
+		case "TestReport":
+			modName, path, _ := strings.Cut(rep.NodeID, "::")
+			if latest[rep.WorkerID] != modName {
+				latest[rep.WorkerID] = modName
+				flush(false)
+			}
+			module := moduleMap[modName]
+			if module == nil {
+				module = &pytestModule{node: &pytestNode{name: modName}, workers: make(map[string]bool)}
+				moduleMap[modName] = module
+				modules = append(modules, module)
+			}
+			module.workers[rep.WorkerID] = true
+			parts := pytestPath(path)
+			if parts == nil {
+				// A test that is its own file, such as a doctest text file.
+				parts = []string{rep.NodeID}
+			}
+			node := module.node
+			for _, part := range parts {
+				node = node.child(part)
+			}
+			if node.result == nil {
+				node.result = &pytestResult{outcome: "passed", sections: make(map[string]bool)}
+			}
+			mergePytestReport(node.result, &rep)
+
+		case "SessionFinish":
+			flush(true)
+		}
+	}
+
+	flush(true)
+	tw.Plan()
+	return exitCode
+}
+
+// pytestPath splits the part of a node ID after the module into nesting
+// levels, giving parametrized cases their own level under the function.
+// It returns nil for an empty path.
+func pytestPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	parts := strings.Split(path, "::")
+	last := parts[len(parts)-1]
+	if i := strings.IndexByte(last, '['); i > 0 && strings.HasSuffix(last, "]") {
+		parts = append(parts[:len(parts)-1], last[:i], last)
+	}
+	return parts
+}
+
+// mergePytestReport folds one phase (setup, call, teardown) into the
+// test's result. The first phase that fails or skips decides the outcome.
+func mergePytestReport(res *pytestResult, rep *pytestReport) {
+	res.duration += rep.Duration
+	// Later phases repeat the sections captured by earlier ones.
+	for _, section := range rep.Sections {
+		if len(section) == 2 && strings.HasPrefix(section[0], "Captured ") && !res.sections[section[0]] {
+			res.sections[section[0]] = true
+			res.output.WriteString(section[1])
+		}
+	}
+
+	if rep.WasXFail != nil {
+		res.xfail = true
+		res.reason = *rep.WasXFail
+	}
+
+	if res.outcome != "passed" || (rep.Outcome == "passed" && rep.WasXFail == nil) {
+		return
+	}
+
+	res.outcome = rep.Outcome
+	res.when = rep.When
+	if rep.WasXFail != nil {
+		// xfail is reported as skipped and xpass as passed; both keep
+		// the xfail reason.
+		return
+	}
+
+	switch rep.Outcome {
+	case "skipped":
+		// Skips serialize longrepr as [path, lineno, "Skipped: reason"].
+		var tuple []any
+		if json.Unmarshal(rep.LongRepr, &tuple) == nil && len(tuple) == 3 {
+			if s, ok := tuple[2].(string); ok {
+				res.reason = strings.TrimPrefix(s, "Skipped: ")
+			}
+		}
+	case "failed":
+		var lr pytestLongRepr
+		if json.Unmarshal(rep.LongRepr, &lr) == nil && lr.ReprCrash != nil {
+			res.message = lr.ReprCrash.Message
+			res.file = lr.ReprCrash.Path
+			res.line = lr.ReprCrash.LineNo
+			res.explain = pytestExplanation(&lr)
+			if len(rep.Location) > 0 {
+				// Prefer the rootdir-relative path pytest uses in node IDs.
+				if loc, ok := rep.Location[0].(string); ok && strings.HasSuffix(res.file, loc) {
+					res.file = loc
+				}
+			}
+		} else {
+			res.message = longReprText(rep.LongRepr)
+		}
+	}
+}
+
+// pytestExplanation returns the "E" lines of a failure's traceback, where
+// pytest's assertion rewriting explains what differed.
+func pytestExplanation(lr *pytestLongRepr) string {
+	if lr.ReprTraceback == nil {
+		return ""
+	}
+	var lines []string
+	for _, entry := range lr.ReprTraceback.ReprEntries {
+		for _, line := range entry.Data.Lines {
+			if strings.HasPrefix(line, "E ") {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func longReprText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// emitPytestNode writes a module, class or parametrized function as a
+// subtest followed by its summary test point, and reports whether anything
+// inside it failed.
+func emitPytestNode(tw *Writer, node *pytestNode, verbose bool) bool {
+	sub := tw.Subtest(node.name)
+	failed := false
+	for _, child := range node.children {
+		if len(child.children) > 0 {
+			failed = emitPytestNode(sub, child, verbose) || failed
+			continue
+		}
+		failed = emitPytestLeaf(sub, child, verbose) || failed
+	}
+	sub.Plan()
+
+	if failed {
+		tw.NotOk(node.name, nil)
+	} else {
+		tw.Ok(node.name)
+	}
+	return failed
+}
+
+func emitPytestLeaf(tw *Writer, node *pytestNode, verbose bool) bool {
+	res := node.result
+	output := strings.TrimSpace(res.output.String())
+
+	switch {
+	case res.xfail && res.outcome == "skipped":
+		tw.Todo(node.name, res.reason)
+	case res.xfail && res.outcome == "passed":
+		tw.TodoPassed(node.name, res.reason)
+	case res.outcome == "skipped":
+		tw.Skip(node.name, res.reason)
+	case res.outcome == "passed":
+		if verbose && output != "" {
+			tw.OkWithDiagnostics(node.name, map[string]string{"output": output})
+		} else {
+			tw.Ok(node.name)
+		}
+	default:
+		diag := map[string]string{
+			"elapsed": fmt.Sprintf("%.3f", res.duration),
+			"when":    res.when,
+		}
+		if res.message != "" {
+			diag["message"] = res.message
+		}
+		if res.file != "" {
+			diag["file"] = res.file
+			diag["line"] = fmt.Sprintf("%d", res.line)
+		}
+		if res.explain != "" {
+			output = strings.TrimSpace(res.explain + "\n\n" + output)
+		}
+		if output != "" {
+			diag["output"] = output
+		}
+		tw.NotOk(node.name, diag)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package tap
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertPytestModulesAndParams(t *testing.T) {
+	reports := strings.Join([]string{
+		`{"pytest_version": "8.3.3", "$report_type": "SessionStart"}`,
+		`{"nodeid": "tests/test_math.py::test_add", "location": ["tests/test_math.py", 2, "test_add"], "outcome": "passed", "longrepr": null, "when": "setup", "sections": [], "duration": 0.0001, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_math.py::test_add", "location": ["tests/test_math.py", 2, "test_add"], "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.0002, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_math.py::test_add", "location": ["tests/test_math.py", 2, "test_add"], "outcome": "passed", "longrepr": null, "when": "teardown", "sections": [], "duration": 0.0001, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_math.py::test_div[1-1]", "location": ["tests/test_math.py", 6, "test_div[1-1]"], "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.0001, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_math.py::test_div[4-2]", "location": ["tests/test_math.py", 6, "test_div[4-2]"], "outcome": "failed", "longrepr": {"reprcrash": {"path": "/src/proj/tests/test_math.py", "lineno": 8, "message": "assert 2 == 3\n +  where 2 = div(4, 2)"}, "reprtraceback": {"reprentries": [], "extraline": null, "style": "long"}, "sections": [], "chain": null}, "when": "call", "sections": [["Captured stdout call", "dividing\n"]], "duration": 0.0003, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_math.py::test_div[4-2]", "location": ["tests/test_math.py", 6, "test_div[4-2]"], "outcome": "passed", "longrepr": null, "when": "teardown", "sections": [["Captured stdout call", "dividing\n"]], "duration": 0.0001, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_misc.py::TestThing::test_skip", "location": ["tests/test_misc.py", 4, "TestThing.test_skip"], "outcome": "skipped", "longrepr": ["/src/proj/tests/test_misc.py", 5, "Skipped: no network"], "when": "setup", "sections": [], "duration": 0.0001, "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_misc.py::test_xfail", "location": ["tests/test_misc.py", 9, "test_xfail"], "outcome": "skipped", "longrepr": null, "when": "call", "sections": [], "duration": 0.0001, "wasxfail": "bug 12", "$report_type": "TestReport"}`,
+		`{"nodeid": "tests/test_misc.py::test_xpass", "location": ["tests/test_misc.py", 13, "test_xpass"], "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.0001, "wasxfail": "bug 13", "$report_type": "TestReport"}`,
+		`{"exitstatus": 1, "$report_type": "SessionFinish"}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertPytest(strings.NewReader(reports), &buf, false)
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "# Subtest: tests/test_math.py") {
+		t.Errorf("expected module subtest:\n%s", out)
+	}
+	if !strings.Contains(out, "        # Subtest: test_div\n        ok 1 - test_div[1-1]\n        not ok 2 - test_div[4-2]\n") {
+		t.Errorf("expected parametrized cases nested under function:\n%s", out)
+	}
+	if !strings.Contains(out, "          file: tests/test_math.py\n          line: 8\n") {
+		t.Errorf("expected relative failure location:\n%s", out)
+	}
+	if !strings.Contains(out, "          message: |\n            assert 2 == 3\n") {
+		t.Errorf("expected assertion message:\n%s", out)
+	}
+	if strings.Count(out, "dividing") != 1 {
+		t.Errorf("expected captured output once:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 1 - test_skip # SKIP no network") {
+		t.Errorf("expected skip with reason:\n%s", out)
+	}
+	if !strings.Contains(out, "not ok 2 - test_xfail # TODO bug 12") {
+		t.Errorf("expected xfail as TODO:\n%s", out)
+	}
+	if !strings.Contains(out, "    ok 3 - test_xpass # TODO bug 13") {
+		t.Errorf("expected xpass as passing TODO:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 2 - tests/test_misc.py") {
+		t.Errorf("expected passing second module:\n%s", out)
+	}
+
+	reader := NewReader(strings.NewReader(out))
+	if !reader.Summary().Valid {
+		t.Errorf("output is not valid TAP-14:\n%s", out)
+	}
+}
+
+func TestConvertPytestCollectionError(t *testing.T) {
+	reports := `{"nodeid": "tests/test_broken.py", "outcome": "failed", "longrepr": "ImportError while importing test module", "result": [], "sections": [], "$report_type": "CollectReport"}` + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertPytest(strings.NewReader(reports), &buf, false)
+
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", exitCode)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "not ok 1 - tests/test_broken.py") {
+		t.Errorf("expected collection failure:\n%s", out)
+	}
+	if !strings.Contains(out, "  message: ImportError while importing test module") {
+		t.Errorf("expected collection error message:\n%s", out)
+	}
+}
+
+func TestConvertPytestInterleavedModules(t *testing.T) {
+	// pytest-xdist workers report as they finish, mixing modules, and
+	// tag each report with the worker that ran it.
+	reports := strings.Join([]string{
+		`{"worker_id": "gw0", "nodeid": "tests/test_a.py::test_one", "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`,
+		`{"worker_id": "gw1", "nodeid": "tests/test_b.py::test_one", "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`,
+		`{"worker_id": "gw0", "nodeid": "tests/test_a.py::test_two", "outcome": "failed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`,
+		`{"worker_id": "gw1", "nodeid": "tests/test_b.py::test_two", "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`,
+		`{"exitstatus": 1, "$report_type": "SessionFinish"}`,
+	}, "\n") + "\n"
+
+	var buf bytes.Buffer
+	exitCode := ConvertPytest(strings.NewReader(reports), &buf, false)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+
+	out := buf.String()
+	want := "    # Subtest: tests/test_a.py\n" +
+		"    ok 1 - test_one\n" +
+		"    not ok 2 - test_two\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected one subtest per module, got:\n%s", out)
+	}
+	for _, module := range []string{"tests/test_a.py", "tests/test_b.py"} {
+		if n := strings.Count(out, "# Subtest: "+module+"\n"); n != 1 {
+			t.Errorf("expected one subtest for %s, got %d:\n%s", module, n, out)
+		}
+	}
+	if !strings.Contains(out, "not ok 1 - tests/test_a.py\n") || !strings.HasSuffix(out, "ok 2 - tests/test_b.py\n1..2\n") {
+		t.Errorf("expected modules in first-report order, got:\n%s", out)
+	}
+}
+
+func TestConvertPytestStreamsModules(t *testing.T) {
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	done := make(chan int)
+	go func() { done <- ConvertPytest(pr, out, false) }()
+
+	io.WriteString(pw, `{"nodeid": "tests/test_a.py::test_one", "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`+"\n")
+	io.WriteString(pw, `{"nodeid": "tests/test_b.py::test_one", "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}`+"\n")
+
+	// The run moved on to test_b, so test_a is written before the session
+	// finishes.
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "ok 1 - tests/test_a.py\n") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected test_a before the session finished, got:\n%s", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(out.String(), "tests/test_b.py") {
+		t.Errorf("expected test_b to wait for its last report, got:\n%s", out.String())
+	}
+
+	pw.Close()
+	<-done
+	if !strings.HasSuffix(out.String(), "ok 2 - tests/test_b.py\n1..2\n") {
+		t.Errorf("expected test_b at the end, got:\n%s", out.String())
+	}
+}
+
+func TestConvertPytestFailureExplanation(t *testing.T) {
+	reports := `{"nodeid": "tests/test_a.py::test_eq", "location": ["tests/test_a.py", 0, "test_eq"], "outcome": "failed", "longrepr": {"reprcrash": {"path": "/src/proj/tests/test_a.py", "lineno": 2, "message": "assert 1 == 2"}, "reprtraceback": {"reprentries": [{"type": "ReprEntry", "data": {"lines": ["    def test_eq():", ">       assert 1 == 2", "E       assert 1 == 2"], "style": "long"}}], "extraline": null, "style": "long"}, "sections": [], "chain": null}, "when": "call", "sections": [["Captured stdout call", "hello\n"]], "duration": 0.1, "$report_type": "TestReport"}` + "\n" +
+		`{"nodeid": "doc.txt", "location": ["doc.txt", 0, "[doctest] doc.txt"], "outcome": "passed", "longrepr": null, "when": "call", "sections": [], "duration": 0.1, "$report_type": "TestReport"}` + "\n"
+
+	var buf bytes.Buffer
+	ConvertPytest(strings.NewReader(reports), &buf, false)
+
+	out := buf.String()
+	if !strings.Contains(out, "      output: |\n        E       assert 1 == 2\n        \n        hello\n") {
+		t.Errorf("expected the E lines and captured output, got:\n%s", out)
+	}
+	if !strings.Contains(out, "    # Subtest: doc.txt\n    ok 1 - doc.txt\n") {
+		t.Errorf("expected a module-level test named by its node ID, got:\n%s", out)
+	}
+}
@@ -30,6 +30,7 @@ type frame struct {
 // Reader is a streaming TAP-14 parser and validator.
 type Reader struct {
 	scanner          *bufio.Scanner
+	src              io.Reader
 	state            readerState
 	lineNum          int
 	stack            []frame
@@ -37,6 +38,9 @@ type Reader struct {
 	done             bool
 	bailed           bool
 	yamlBuf          map[string]string
+	yamlBlockKey     string
+	yamlBlockLines   []string
+	yamlBlockFolded  bool
 	lastWasTestPoint bool
 	passed           int
 	failed           int
@@ -48,6 +52,7 @@ type Reader struct {
 func NewReader(r io.Reader) *Reader {
 	return &Reader{
 		scanner: bufio.NewScanner(r),
+		src:     r,
 		stack:   []frame{{depth: 0}},
 	}
 }
@@ -81,6 +86,7 @@ func (r *Reader) Next() (Event, error) {
 		if r.state == stateYAML {
 			expectedIndent := (r.currentFrame().depth * 4) + 2
 			if raw == strings.Repeat(" ", expectedIndent)+"..." {
+				r.endYAMLBlockScalar()
 				r.state = stateBody
 				yaml := r.yamlBuf
 				r.yamlBuf = nil
@@ -97,11 +103,24 @@ func (r *Reader) Next() (Event, error) {
 			if len(content) >= expectedIndent {
 				content = content[expectedIndent:]
 			}
+			if r.yamlBlockKey != "" && (content == "" || content[0] == ' ') {
+				r.yamlBlockLines = append(r.yamlBlockLines, content)
+				continue
+			}
+			r.endYAMLBlockScalar()
 			parts := strings.SplitN(content, ":", 2)
 			if len(parts) == 2 {
 				key := strings.TrimSpace(parts[0])
 				val := strings.TrimSpace(parts[1])
-				r.yamlBuf[key] = val
+				if val == "" || val == "|" || val == "|-" || val == ">" || val == ">-" {
+					// Block scalar: the value is on the following, more
+					// deeply indented lines. A nested list or map under
+					// an empty value is kept as its YAML text.
+					r.yamlBlockKey = key
+					r.yamlBlockFolded = strings.HasPrefix(val, ">")
+					continue
+				}
+				r.yamlBuf[key] = yamlUnquote(val)
 			}
 			continue
 		}
@@ -249,6 +268,59 @@ func (r *Reader) Next() (Event, error) {
 	return Event{}, io.EOF
 }
 
+// yamlUnquote returns the value of a quoted YAML scalar, such as the
+// Writer writes for values that would not read back plain, and any other
+// value as it is.
+func yamlUnquote(val string) string {
+	if len(val) < 2 {
+		return val
+	}
+	switch {
+	case val[0] == '"' && val[len(val)-1] == '"':
+		if s, err := strconv.Unquote(val); err == nil {
+			return s
+		}
+	case val[0] == '\'' && val[len(val)-1] == '\'':
+		return strings.ReplaceAll(val[1:len(val)-1], "''", "'")
+	}
+	return val
+}
+
+// endYAMLBlockScalar stores a pending block scalar value, with the
+// indentation of its first line removed from every line.
+func (r *Reader) endYAMLBlockScalar() {
+	if r.yamlBlockKey == "" {
+		return
+	}
+	lines := r.yamlBlockLines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent = len(line) - len(strings.TrimLeft(line, " "))
+		break
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent >= 0 {
+			lines[i] = line[indent:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " ")
+		}
+	}
+	sep := "\n"
+	if r.yamlBlockFolded {
+		sep = " "
+	}
+	r.yamlBuf[r.yamlBlockKey] = strings.Join(lines, sep)
+	r.yamlBlockKey = ""
+	r.yamlBlockLines = nil
+	r.yamlBlockFolded = false
+}
+
 func (r *Reader) finalize() {
 	if r.state == stateStart {
 		r.addDiag(SeverityError, "version-required", "first line must be TAP version 14")
@@ -294,6 +366,12 @@ func (r *Reader) Summary() Summary {
 		}
 	}
 
+	return r.snapshot()
+}
+
+// snapshot builds a Summary from the counters accumulated so far without
+// consuming any further input.
+func (r *Reader) snapshot() Summary {
 	s := Summary{
 		Version:   14,
 		BailedOut: r.bailed,
@@ -325,6 +403,7 @@ func (r *Reader) Summary() Summary {
 // collecting diagnostics.
 func (r *Reader) ReadFrom(src io.Reader) (int64, error) {
 	r.scanner = bufio.NewScanner(src)
+	r.src = src
 	r.lineNum = 0
 	r.state = stateStart
 	r.stack = []frame{{depth: 0}}
@@ -353,21 +432,27 @@ func (r *Reader) WriteTo(w io.Writer) (int64, error) {
 	summary := r.Summary()
 
 	for _, d := range r.diags {
-		line := fmt.Sprintf("line %d: %s: [%s] %s\n", d.Line, d.Severity, d.Rule, d.Message)
-		n, err := io.WriteString(w, line)
+		n, err := io.WriteString(w, formatDiagnostic(d))
 		total += int64(n)
 		if err != nil {
 			return total, err
 		}
 	}
 
+	n, err := io.WriteString(w, "\n"+formatSummary(summary))
+	total += int64(n)
+	return total, err
+}
+
+func formatDiagnostic(d Diagnostic) string {
+	return fmt.Sprintf("line %d: %s: [%s] %s\n", d.Line, d.Severity, d.Rule, d.Message)
+}
+
+func formatSummary(s Summary) string {
 	status := "valid"
-	if !summary.Valid {
+	if !s.Valid {
 		status = "invalid"
 	}
-	line := fmt.Sprintf("\n%s: %d tests (%d passed, %d failed, %d skipped, %d todo)\n",
-		status, summary.TotalTests, summary.Passed, summary.Failed, summary.Skipped, summary.Todo)
-	n, err := io.WriteString(w, line)
-	total += int64(n)
-	return total, err
+	return fmt.Sprintf("%s: %d tests (%d passed, %d failed, %d skipped, %d todo)\n",
+		status, s.TotalTests, s.Passed, s.Failed, s.Skipped, s.Todo)
 }
@@ -145,6 +145,61 @@ func TestReaderYAMLBlock(t *testing.T) {
 	}
 }
 
+func TestReaderYAMLBlockScalar(t *testing.T) {
+	input := "TAP version 14\n1..1\nnot ok 1 - fail\n  ---\n  message: |\n    foo_test.go:10: expected 1\n      got 2\n  file: foo_test.go\n  ...\n"
+	events, diags, _ := collectEvents(input)
+
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			t.Errorf("unexpected error: %s: %s", d.Rule, d.Message)
+		}
+	}
+
+	for _, ev := range events {
+		if ev.Type != EventYAMLDiagnostic {
+			continue
+		}
+		want := "foo_test.go:10: expected 1\n  got 2"
+		if ev.YAML["message"] != want {
+			t.Errorf("YAML message = %q, want %q", ev.YAML["message"], want)
+		}
+		if ev.YAML["file"] != "foo_test.go" {
+			t.Errorf("YAML file = %q, want %q", ev.YAML["file"], "foo_test.go")
+		}
+		if _, ok := ev.YAML["foo_test.go"]; ok {
+			t.Error("block scalar lines should not be parsed as keys")
+		}
+		return
+	}
+	t.Error("expected YAML diagnostic event")
+}
+
+func TestReaderYAMLNestedList(t *testing.T) {
+	input := "TAP version 14\n1..1\nnot ok 1 - fail\n  ---\n  file: a_test.go\n  locations:\n    - file: b_test.go\n      line: 20\n  line: 9\n  ...\n"
+	events, diags, _ := collectEvents(input)
+
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			t.Errorf("unexpected error: %s: %s", d.Rule, d.Message)
+		}
+	}
+
+	for _, ev := range events {
+		if ev.Type != EventYAMLDiagnostic {
+			continue
+		}
+		if ev.YAML["file"] != "a_test.go" || ev.YAML["line"] != "9" {
+			t.Errorf("list entries should not replace top-level keys, got file %q line %q", ev.YAML["file"], ev.YAML["line"])
+		}
+		want := "- file: b_test.go\n  line: 20"
+		if ev.YAML["locations"] != want {
+			t.Errorf("YAML locations = %q, want %q", ev.YAML["locations"], want)
+		}
+		return
+	}
+	t.Error("expected YAML diagnostic event")
+}
+
 func TestReaderBailOut(t *testing.T) {
 	input := "TAP version 14\n1..3\nok 1 - a\nBail out! database down\n"
 	_, _, summary := collectEvents(input)
@@ -299,3 +354,22 @@ func TestReaderUnclosedYAML(t *testing.T) {
 		t.Error("expected yaml-unclosed diagnostic")
 	}
 }
+
+func TestReaderYAMLFoldedScalar(t *testing.T) {
+	input := "TAP version 14\n1..1\nnot ok 1 - fail\n  ---\n  message: >-\n    expected one\n    got two\n  severity: fail\n  ...\n"
+	events, _, _ := collectEvents(input)
+
+	for _, ev := range events {
+		if ev.Type != EventYAMLDiagnostic {
+			continue
+		}
+		if ev.YAML["message"] != "expected one got two" {
+			t.Errorf("YAML message = %q, want %q", ev.YAML["message"], "expected one got two")
+		}
+		if ev.YAML["severity"] != "fail" {
+			t.Errorf("YAML severity = %q, want %q", ev.YAML["severity"], "fail")
+		}
+		return
+	}
+	t.Error("expected YAML diagnostic event")
+}
@@ -0,0 +1,175 @@
+package tap
+
+import "strings"
+
+// tapNode is a test point in the tree rebuilt from a TAP stream's events.
+// Subtests are nodes with children, closed by the test point that follows
+// them at the parent's depth.
+type tapNode struct {
+	name     string
+	point    *TestPointResult
+	yaml     map[string]string
+	children []*tapNode
+	bailOut  string
+	line     int
+}
+
+// buildTapTree consumes the reader and returns the root of the test tree.
+// A "# Subtest: name" comment names a subtest; otherwise the closing test
+// point's description does. If onEvent is non-nil it sees every event.
+func buildTapTree(r *Reader, onEvent func(Event)) *tapNode {
+	root := &tapNode{}
+	stack := []*tapNode{root}
+	// closing[d] is a subtest at depth d+1 that has ended and awaits its
+	// closing test point at depth d.
+	closing := map[int]*tapNode{}
+	var last *tapNode
+
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+		if onEvent != nil {
+			onEvent(ev)
+		}
+
+		for ev.Depth < len(stack)-1 {
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			closing[len(stack)-1] = done
+		}
+		for ev.Depth > len(stack)-1 {
+			child := &tapNode{}
+			stack = append(stack, child)
+		}
+		parent := stack[len(stack)-1]
+
+		switch ev.Type {
+		case EventComment:
+			if name, ok := strings.CutPrefix(ev.Comment, "Subtest:"); ok && len(parent.children) == 0 && parent.point == nil {
+				parent.name = strings.TrimSpace(name)
+			}
+		case EventTestPoint:
+			node := closing[ev.Depth]
+			delete(closing, ev.Depth)
+			if node == nil {
+				node = &tapNode{}
+			}
+			if node.name == "" {
+				node.name = ev.TestPoint.Description
+			}
+			node.point = ev.TestPoint
+			node.line = ev.Line
+			parent.children = append(parent.children, node)
+			last = node
+		case EventYAMLDiagnostic:
+			if last != nil && last.yaml == nil {
+				last.yaml = ev.YAML
+			}
+		case EventBailOut:
+			parent.children = append(parent.children, &tapNode{name: "Bail out!", bailOut: ev.BailOut.Reason, line: ev.Line})
+		}
+	}
+
+	// Subtests still open at EOF never got a closing test point.
+	for d := range stack {
+		if node := closing[d]; node != nil {
+			stack[d].children = append(stack[d].children, node)
+		}
+	}
+	for i := len(stack) - 1; i > 0; i-- {
+		stack[i-1].children = append(stack[i-1].children, stack[i])
+	}
+	return root
+}
+
+// testCounts counts leaf test points by outcome.
+type testCounts struct {
+	Total, Passed, Failed, Skipped, Todo int
+}
+
+func (c *testCounts) add(o testCounts) {
+	c.Total += o.Total
+	c.Passed += o.Passed
+	c.Failed += o.Failed
+	c.Skipped += o.Skipped
+	c.Todo += o.Todo
+}
+
+type testFailure struct {
+	name    string
+	message string
+	file    string
+	line    string
+}
+
+type testSkip struct {
+	name      string
+	directive Directive
+	reason    string
+}
+
+// testResults is a flat view of a test tree for reporters that list
+// failures and skips rather than render the tree.
+type testResults struct {
+	counts   testCounts
+	failures []testFailure
+	skipped  []testSkip
+}
+
+// collect walks a test tree node, counting its leaf test points and
+// recording failures, skips and TODOs under their path through subtests.
+// A subtest that failed on its own, or never closed, is itself a failure.
+func (res *testResults) collect(n *tapNode, prefix string) {
+	name := prefix + n.name
+
+	if n.bailOut != "" || (n.point == nil && len(n.children) == 0) {
+		res.failures = append(res.failures, testFailure{name: "Bail out!", message: firstNonEmpty(n.bailOut, "Bail out!")})
+		return
+	}
+
+	if len(n.children) > 0 {
+		before := len(res.failures)
+		for _, child := range n.children {
+			res.collect(child, name+"/")
+		}
+		childFailed := len(res.failures) > before
+		if (n.point == nil && !childFailed) || failedOnItsOwn(n.point, childFailed) {
+			res.failures = append(res.failures, newTestFailure(name, n))
+		}
+		return
+	}
+
+	res.counts.Total++
+	switch tp := n.point; {
+	case tp.Directive == DirectiveSkip:
+		res.counts.Skipped++
+		res.skipped = append(res.skipped, testSkip{name: name, directive: tp.Directive, reason: tp.Reason})
+	case tp.Directive == DirectiveTodo:
+		res.counts.Todo++
+		res.skipped = append(res.skipped, testSkip{name: name, directive: tp.Directive, reason: tp.Reason})
+	case !tp.OK:
+		res.counts.Failed++
+		res.failures = append(res.failures, newTestFailure(name, n))
+	default:
+		res.counts.Passed++
+	}
+}
+
+func newTestFailure(name string, n *tapNode) testFailure {
+	return testFailure{
+		name:    name,
+		message: firstNonEmpty(n.yaml["message"], n.yaml["output"], name+" failed"),
+		file:    n.yaml["file"],
+		line:    n.yaml["line"],
+	}
+}
+
+// failedOnItsOwn reports whether a subtest's closing test point failed
+// without any failing test inside it, as when a package panicked. Reporters
+// that only show leaf tests must report such a subtest as a failure itself,
+// or the failure would vanish.
+func failedOnItsOwn(point *TestPointResult, childFailed bool) bool {
+	return point != nil && !point.OK && point.Directive == DirectiveNone && !childFailed
+}
@@ -0,0 +1,22 @@
+package tap
+
+import "testing"
+
+func TestFailedOnItsOwn(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		point       *TestPointResult
+		childFailed bool
+		want        bool
+	}{
+		{"unclosed", nil, false, false},
+		{"passed", &TestPointResult{OK: true}, false, false},
+		{"failed alone", &TestPointResult{OK: false}, false, true},
+		{"failed child", &TestPointResult{OK: false}, true, false},
+		{"todo", &TestPointResult{OK: false, Directive: DirectiveTodo}, false, false},
+	} {
+		if got := failedOnItsOwn(tc.point, tc.childFailed); got != tc.want {
+			t.Errorf("%s: failedOnItsOwn = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
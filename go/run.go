@@ -0,0 +1,194 @@
+package tap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RunOptions configures RunScripts.
+type RunOptions struct {
+	// Jobs is the maximum number of scripts running at once. Values below
+	// one run scripts serially.
+	Jobs int
+	// Timeout limits each script's run time. Zero means no limit.
+	Timeout time.Duration
+	// Stderr receives the scripts' standard error. Nil discards it.
+	Stderr io.Writer
+}
+
+type scriptResult struct {
+	output   []byte
+	exitCode int
+	elapsed  time.Duration
+	state    *os.ProcessState
+	timedOut bool
+	err      error
+}
+
+// RunScripts runs TAP-producing executables or scripts in the spirit of
+// prove, validates each stream with a Reader, and writes one TAP-14
+// document to w with each script as a named subtest. Scripts run
+// concurrently up to opts.Jobs but are reported in argument order, each
+// once it has exited, so a script's output is buffered until then. A script
+// that exceeds opts.Timeout is killed, and it or one that cannot be run
+// becomes a not ok test point carrying whatever output it wrote.
+// Returns an exit code following Verdict.ExitCode across all scripts.
+func RunScripts(ctx context.Context, scripts []string, w io.Writer, opts RunOptions) int {
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	sem := make(chan struct{}, jobs)
+	results := make([]chan scriptResult, len(scripts))
+	for i, script := range scripts {
+		results[i] = make(chan scriptResult, 1)
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] <- runScript(ctx, script, opts)
+		}()
+	}
+
+	tw := NewWriter(w)
+	exitCode := ExitPassed
+	for i, script := range scripts {
+		if code := emitScript(tw, script, <-results[i], opts.Timeout); code > exitCode {
+			exitCode = code
+		}
+	}
+	tw.Plan()
+	return exitCode
+}
+
+func runScript(ctx context.Context, script string, opts RunOptions) scriptResult {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	name, args, err := scriptCommand(script)
+	if err != nil {
+		return scriptResult{err: err}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Grandchildren that inherit stdout would otherwise keep Wait blocked
+	// after a timeout kills the script itself.
+	cmd.WaitDelay = time.Second
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = opts.Stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	res := scriptResult{
+		output:  stdout.Bytes(),
+		elapsed: time.Since(start),
+		state:   cmd.ProcessState,
+	}
+	if res.state != nil {
+		res.exitCode = res.state.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.timedOut = true
+	case err != nil && !errors.As(err, &exitErr):
+		res.err = err
+	}
+	return res
+}
+
+// scriptCommand runs executables directly and shell scripts that lack the
+// executable bit through bash.
+func scriptCommand(script string) (string, []string, error) {
+	if info, err := os.Stat(script); err == nil && info.Mode()&0o111 == 0 {
+		switch filepath.Ext(script) {
+		case ".sh", ".bash":
+			return "bash", []string{script}, nil
+		}
+		return "", nil, fmt.Errorf("%s is not executable and has no known interpreter for its extension (.sh and .bash run through bash)", script)
+	}
+	if !strings.ContainsRune(script, filepath.Separator) {
+		script = "." + string(filepath.Separator) + script
+	}
+	return script, nil, nil
+}
+
+func emitScript(tw *Writer, script string, res scriptResult, timeout time.Duration) int {
+	diag := map[string]string{
+		"elapsed": fmt.Sprintf("%.3f", res.elapsed.Seconds()),
+	}
+	if res.state != nil {
+		diag["exit"] = fmt.Sprintf("%d", res.exitCode)
+		diag["user"] = fmt.Sprintf("%.3f", res.state.UserTime().Seconds())
+		diag["system"] = fmt.Sprintf("%.3f", res.state.SystemTime().Seconds())
+		if rss, ok := maxRSS(res.state); ok {
+			diag["maxrss"] = fmt.Sprintf("%d", rss)
+		}
+	}
+
+	// A script cut short has an unfinished stream; a bail out would end
+	// the whole combined document, so it is reported as a failure of its
+	// own with the partial output in YAML instead.
+	var message string
+	switch {
+	case res.err != nil:
+		message = fmt.Sprintf("failed to run: %v", res.err)
+	case res.timedOut:
+		message = fmt.Sprintf("timed out after %s", timeout)
+	}
+	if message != "" {
+		diag["message"] = message
+		if output := strings.TrimSpace(string(res.output)); output != "" {
+			diag["output"] = output
+		}
+		tw.NotOk(script, diag)
+		return ExitTestsFailed
+	}
+
+	sub := tw.Subtest(script)
+
+	scanner := bufio.NewScanner(bytes.NewReader(res.output))
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		// The combined document carries the only version line.
+		if first && line == "TAP version 14" {
+			first = false
+			continue
+		}
+		first = false
+		sub.passthrough(line)
+	}
+
+	verdict := NewReader(bytes.NewReader(res.output)).Verdict()
+	if res.exitCode != 0 {
+		verdict.Reasons = append(verdict.Reasons, ReasonExitStatus)
+		verdict.Passed = false
+	}
+
+	if verdict.Passed {
+		tw.OkWithDiagnostics(script, diag)
+		return ExitPassed
+	}
+
+	reasons := make([]string, len(verdict.Reasons))
+	for i, r := range verdict.Reasons {
+		reasons[i] = r.String()
+	}
+	diag["reasons"] = strings.Join(reasons, ", ")
+	tw.NotOk(script, diag)
+	return verdict.ExitCode()
+}
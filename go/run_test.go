@@ -0,0 +1,115 @@
+package tap
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunScriptsCombinesSubtests(t *testing.T) {
+	dir := t.TempDir()
+	a := writeScript(t, dir, "a.t", "echo 'TAP version 14'\necho 'ok 1 - first'\necho '1..1'\n")
+	b := writeScript(t, dir, "b.t", "echo 'TAP version 14'\necho '1..1'\necho 'not ok 1 - second'\n")
+
+	var buf bytes.Buffer
+	exitCode := RunScripts(context.Background(), []string{a, b}, &buf, RunOptions{Jobs: 2})
+	if exitCode != ExitTestsFailed {
+		t.Errorf("expected exit code %d, got %d", ExitTestsFailed, exitCode)
+	}
+
+	out := buf.String()
+	if strings.Count(out, "TAP version 14") != 1 {
+		t.Errorf("expected a single version line, got:\n%s", out)
+	}
+	if !strings.Contains(out, "    # Subtest: "+a+"\n    ok 1 - first\n") {
+		t.Errorf("expected first script as subtest, got:\n%s", out)
+	}
+	if !strings.Contains(out, "ok 1 - "+a+"\n  ---\n") {
+		t.Errorf("expected passing script with YAML, got:\n%s", out)
+	}
+	if !strings.Contains(out, "not ok 2 - "+b+"\n") {
+		t.Errorf("expected failing script, got:\n%s", out)
+	}
+	if !strings.Contains(out, "  reasons: tests-failed\n") {
+		t.Errorf("expected failure reason, got:\n%s", out)
+	}
+
+	summary := NewReader(strings.NewReader(out)).Summary()
+	if !summary.Valid {
+		t.Errorf("expected valid TAP, got:\n%s", out)
+	}
+}
+
+func TestRunScriptsNonZeroExit(t *testing.T) {
+	dir := t.TempDir()
+	s := writeScript(t, dir, "exit.t", "echo 'TAP version 14'\necho '1..1'\necho 'ok 1 - fine'\nexit 4\n")
+
+	var buf bytes.Buffer
+	exitCode := RunScripts(context.Background(), []string{s}, &buf, RunOptions{})
+	if exitCode != ExitTestsFailed {
+		t.Errorf("expected exit code %d, got %d", ExitTestsFailed, exitCode)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "  exit: 4\n") || !strings.Contains(out, "  reasons: exit-status\n") {
+		t.Errorf("expected exit status in YAML, got:\n%s", out)
+	}
+}
+
+func TestRunScriptsTimeout(t *testing.T) {
+	dir := t.TempDir()
+	s := writeScript(t, dir, "slow.t", "echo 'TAP version 14'\necho 'ok 1 - before'\nexec sleep 10\n")
+	after := writeScript(t, dir, "after.t", "echo 'TAP version 14'\necho '1..1'\necho 'ok 1 - after'\n")
+
+	var buf bytes.Buffer
+	exitCode := RunScripts(context.Background(), []string{s, after}, &buf, RunOptions{Timeout: 100 * time.Millisecond})
+	if exitCode != ExitTestsFailed {
+		t.Errorf("expected exit code %d, got %d", ExitTestsFailed, exitCode)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"not ok 1 - " + s + "\n",
+		"  message: timed out after 100ms\n",
+		"  exit: -1\n",
+		"  output: |\n    TAP version 14\n    ok 1 - before\n",
+		"ok 2 - " + after + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+
+	// The later script's result stands: the document fails rather than
+	// bailing out.
+	if v := NewReader(strings.NewReader(out)).Verdict(); v.ExitCode() != ExitTestsFailed {
+		t.Errorf("expected verdict exit code %d, got %d:\n%s", ExitTestsFailed, v.ExitCode(), out)
+	}
+}
+
+func TestRunScriptsNotExecutable(t *testing.T) {
+	dir := t.TempDir()
+	s := filepath.Join(dir, "plain.t")
+	if err := os.WriteFile(s, []byte("echo 'ok 1'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if exitCode := RunScripts(context.Background(), []string{s}, &buf, RunOptions{}); exitCode != ExitTestsFailed {
+		t.Errorf("expected exit code %d, got %d", ExitTestsFailed, exitCode)
+	}
+	if !strings.Contains(buf.String(), "is not executable and has no known interpreter") {
+		t.Errorf("expected not-executable message, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tap
+
+import "os"
+
+func maxRSS(state *os.ProcessState) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package tap
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// maxRSS reports the peak resident set size of a finished process in
+// kilobytes.
+func maxRSS(state *os.ProcessState) (int64, bool) {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0, false
+	}
+	rss := int64(ru.Maxrss)
+	if runtime.GOOS == "darwin" {
+		rss /= 1024
+	}
+	return rss, true
+}
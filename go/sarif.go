@@ -0,0 +1,197 @@
+package tap
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SARIFOptions configures WriteSARIF.
+type SARIFOptions struct {
+	// ArtifactURI names the TAP stream that protocol results point into.
+	// Defaults to "stdin".
+	ArtifactURI string
+	// Tests also reports failed test points whose YAML diagnostics carry
+	// file and line keys, located in the source file under test.
+	Tests bool
+}
+
+// ruleDescriptions gives the SARIF short description of each rule the
+// Reader and WriteSARIF report.
+var ruleDescriptions = map[string]string{
+	"version-required":     "The stream must begin with TAP version 14.",
+	"plan-required":        "The stream must contain a plan line.",
+	"plan-duplicate":       "The stream must contain only one plan line.",
+	"plan-count-mismatch":  "The number of test points must match the plan.",
+	"subtest-version":      "Subtests should not repeat the version line.",
+	"test-number-missing":  "Test points should be numbered.",
+	"test-number-sequence": "Test point numbers should be sequential.",
+	"yaml-indent":          "YAML blocks must be indented two spaces past their test point.",
+	"yaml-orphan":          "YAML blocks must follow a test point.",
+	"yaml-unclosed":        "YAML blocks must be opened with --- and closed with ....",
+	"test-failed":          "A test point reported not ok.",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool      sarifTool       `json:"tool"`
+	Artifacts []sarifArtifact `json:"artifacts"`
+	Results   []sarifResult   `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifArtifact struct {
+	Location sarifArtifactLocation `json:"location"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	RuleIndex        int             `json:"ruleIndex"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF reads a TAP stream and writes its protocol diagnostics to w as
+// a SARIF 2.1.0 log. Each diagnostic becomes a result of its rule, located
+// at its line and indentation in the TAP artifact; rules appear in the
+// order they were first reported.
+func WriteSARIF(r *Reader, w io.Writer, opts SARIFOptions) error {
+	uri := opts.ArtifactURI
+	if uri == "" {
+		uri = "stdin"
+	}
+
+	// Columns point past the indentation of the reported line.
+	columns := make(map[int]int)
+	root := buildTapTree(r, func(ev Event) {
+		columns[ev.Line] = len(ev.Raw) - len(strings.TrimLeft(ev.Raw, " ")) + 1
+	})
+
+	run := sarifRun{
+		Tool:      sarifTool{Driver: sarifDriver{Name: "tap-dancer", Rules: []sarifRule{}}},
+		Artifacts: []sarifArtifact{{Location: sarifArtifactLocation{URI: uri}}},
+		Results:   []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	rule := func(id, level string) int {
+		if i, ok := ruleIndex[id]; ok {
+			return i
+		}
+		ruleIndex[id] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{Text: firstNonEmpty(ruleDescriptions[id], id)},
+			DefaultConfiguration: sarifConfiguration{Level: level},
+		})
+		return ruleIndex[id]
+	}
+	tapLocation := func(line int) sarifLocation {
+		return sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: uri},
+			Region:           sarifRegion{StartLine: max(line, 1), StartColumn: max(columns[line], 1)},
+		}}
+	}
+
+	for _, d := range r.Diagnostics() {
+		level := d.Severity.String()
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    d.Rule,
+			RuleIndex: rule(d.Rule, level),
+			Level:     level,
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{tapLocation(d.Line)},
+		})
+	}
+
+	if opts.Tests {
+		var visit func(n *tapNode, prefix string)
+		visit = func(n *tapNode, prefix string) {
+			name := prefix + n.name
+			for _, child := range n.children {
+				visit(child, name+"/")
+			}
+			if len(n.children) > 0 || n.point == nil || n.point.OK || n.point.Directive != DirectiveNone {
+				return
+			}
+			file := n.yaml["file"]
+			line, err := strconv.Atoi(n.yaml["line"])
+			if file == "" || err != nil {
+				return
+			}
+			message := name + " failed"
+			if m := n.yaml["message"]; m != "" {
+				message += ": " + m
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    "test-failed",
+				RuleIndex: rule("test-failed", "error"),
+				Level:     "error",
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: file},
+					Region:           sarifRegion{StartLine: line},
+				}}},
+				RelatedLocations: []sarifLocation{tapLocation(n.line)},
+			})
+		}
+		for _, child := range root.children {
+			visit(child, "")
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
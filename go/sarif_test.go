@@ -0,0 +1,108 @@
+package tap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func writeSARIFLog(t *testing.T, input string, opts SARIFOptions) sarifLog {
+	t.Helper()
+	var buf strings.Builder
+	if err := WriteSARIF(NewReader(strings.NewReader(input)), &buf, opts); err != nil {
+		t.Fatalf("WriteSARIF error: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(buf.String()), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	return log
+}
+
+func TestWriteSARIFDiagnostics(t *testing.T) {
+	input := "TAP version 14\n1..3\nok 1 - a\n    ok 3 - nested\nok 3 - b\n"
+	log := writeSARIFLog(t, input, SARIFOptions{ArtifactURI: "out.tap"})
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected one SARIF 2.1.0 run, got %+v", log)
+	}
+	run := log.Runs[0]
+
+	var found bool
+	for _, res := range run.Results {
+		rule := run.Tool.Driver.Rules[res.RuleIndex]
+		if rule.ID != res.RuleID {
+			t.Errorf("result rule %q has index of rule %q", res.RuleID, rule.ID)
+		}
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("rule %q has no description", rule.ID)
+		}
+		if res.RuleID == "plan-count-mismatch" {
+			found = true
+			if res.Level != "error" {
+				t.Errorf("expected error level, got %q", res.Level)
+			}
+			loc := res.Locations[0].PhysicalLocation
+			if loc.ArtifactLocation.URI != "out.tap" {
+				t.Errorf("expected location in out.tap, got %q", loc.ArtifactLocation.URI)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected plan-count-mismatch result, got %+v", run.Results)
+	}
+}
+
+func TestWriteSARIFColumnFollowsIndent(t *testing.T) {
+	input := "TAP version 14\n1..1\n# Subtest: s\n    1..2\n    ok 1 - a\n    ok 3 - b\nok 1 - s\n"
+	log := writeSARIFLog(t, input, SARIFOptions{})
+
+	for _, res := range log.Runs[0].Results {
+		if res.RuleID != "test-number-sequence" {
+			continue
+		}
+		if res.Level != "warning" {
+			t.Errorf("expected warning level, got %q", res.Level)
+		}
+		region := res.Locations[0].PhysicalLocation.Region
+		if region.StartLine != 6 || region.StartColumn != 5 {
+			t.Errorf("expected 6:5, got %d:%d", region.StartLine, region.StartColumn)
+		}
+		return
+	}
+	t.Errorf("expected test-number-sequence result, got %+v", log.Runs[0].Results)
+}
+
+func TestWriteSARIFTests(t *testing.T) {
+	input := `TAP version 14
+1..2
+not ok 1 - located
+  ---
+  file: pkg/a_test.go
+  line: 12
+  message: boom
+  ...
+not ok 2 - unlocated
+`
+	log := writeSARIFLog(t, input, SARIFOptions{})
+	if n := len(log.Runs[0].Results); n != 0 {
+		t.Errorf("expected no test results without Tests, got %d", n)
+	}
+
+	log = writeSARIFLog(t, input, SARIFOptions{Tests: true})
+	results := log.Runs[0].Results
+	if len(results) != 1 {
+		t.Fatalf("expected one test result, got %+v", results)
+	}
+	res := results[0]
+	loc := res.Locations[0].PhysicalLocation
+	if res.RuleID != "test-failed" || loc.ArtifactLocation.URI != "pkg/a_test.go" || loc.Region.StartLine != 12 {
+		t.Errorf("unexpected test result %+v", res)
+	}
+	if res.Message.Text != "located failed: boom" {
+		t.Errorf("unexpected message %q", res.Message.Text)
+	}
+	if res.RelatedLocations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Errorf("expected related location at TAP line 3, got %+v", res.RelatedLocations)
+	}
+}
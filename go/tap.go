@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 type Writer struct {
@@ -27,31 +29,112 @@ func (tw *Writer) Ok(description string) int {
 func (tw *Writer) NotOk(description string, diagnostics map[string]string) int {
 	tw.n++
 	fmt.Fprintf(tw.w, "not ok %d - %s\n", tw.n, description)
-	if len(diagnostics) > 0 {
-		fmt.Fprintln(tw.w, "  ---")
-		keys := make([]string, 0, len(diagnostics))
-		for k := range diagnostics {
+	tw.writeDiagnostics(diagnostics)
+	return tw.n
+}
+
+// OkWithDiagnostics emits a passing test point followed by a YAML
+// diagnostic block, for results worth annotating even when they pass.
+func (tw *Writer) OkWithDiagnostics(description string, diagnostics map[string]string) int {
+	tw.n++
+	fmt.Fprintf(tw.w, "ok %d - %s\n", tw.n, description)
+	tw.writeDiagnostics(diagnostics)
+	return tw.n
+}
+
+// Location is a place in the source a diagnostic points at, such as one of
+// the t.Errorf calls of a failed test.
+type Location struct {
+	File    string
+	Line    string
+	Message string
+}
+
+// NotOkWithLocations emits a failing test point followed by a YAML
+// diagnostic block that also lists locations, in order, under the
+// locations key.
+func (tw *Writer) NotOkWithLocations(description string, diagnostics map[string]string, locations []Location) int {
+	tw.n++
+	fmt.Fprintf(tw.w, "not ok %d - %s\n", tw.n, description)
+	tw.writeYAML(diagnostics, locations)
+	return tw.n
+}
+
+func (tw *Writer) writeDiagnostics(diagnostics map[string]string) {
+	tw.writeYAML(diagnostics, nil)
+}
+
+func (tw *Writer) writeYAML(diagnostics map[string]string, locations []Location) {
+	if len(diagnostics) == 0 && len(locations) == 0 {
+		return
+	}
+	fmt.Fprintln(tw.w, "  ---")
+	keys := make([]string, 0, len(diagnostics)+1)
+	for k := range diagnostics {
+		if k != "locations" || len(locations) == 0 {
 			keys = append(keys, k)
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			v := diagnostics[k]
-			if strings.Contains(v, "\n") {
-				fmt.Fprintf(tw.w, "  %s: |\n", k)
-				lines := strings.Split(v, "\n")
-				for len(lines) > 0 && lines[len(lines)-1] == "" {
-					lines = lines[:len(lines)-1]
-				}
-				for _, line := range lines {
-					fmt.Fprintf(tw.w, "    %s\n", line)
+	}
+	if len(locations) > 0 {
+		keys = append(keys, "locations")
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k != "locations" || len(locations) == 0 {
+			tw.writeYAMLValue("  ", "  ", k, diagnostics[k])
+			continue
+		}
+		fmt.Fprintln(tw.w, "  locations:")
+		for _, loc := range locations {
+			// The first field of each entry carries the list's dash.
+			first := "    - "
+			for _, f := range [][2]string{{"file", loc.File}, {"line", loc.Line}, {"message", loc.Message}} {
+				if f[1] == "" {
+					continue
 				}
-			} else {
-				fmt.Fprintf(tw.w, "  %s: %s\n", k, v)
+				tw.writeYAMLValue(first, "      ", f[0], f[1])
+				first = "      "
 			}
 		}
-		fmt.Fprintln(tw.w, "  ...")
 	}
-	return tw.n
+	fmt.Fprintln(tw.w, "  ...")
+}
+
+// writeYAMLValue writes key: value after prefix, with a multi-line value
+// as a literal block indented past indent.
+func (tw *Writer) writeYAMLValue(prefix, indent, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(tw.w, "%s%s: %s\n", prefix, key, yamlScalar(value))
+		return
+	}
+	fmt.Fprintf(tw.w, "%s%s: |\n", prefix, key)
+	lines := strings.Split(value, "\n")
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(tw.w, "%s  %s\n", indent, line)
+	}
+}
+
+// yamlScalar returns a single-line value as a YAML scalar, quoted if YAML
+// would read it plain as something else: empty, padded, starting with an
+// indicator character, or containing ": " or " #". Single quotes need no
+// escapes but cannot hold control characters; double quotes can.
+func yamlScalar(value string) string {
+	// "-", "?" and ":" only start a plain scalar before a space, as in
+	// "- item", so "-1" stays plain.
+	indicator := strings.ContainsAny(value[:min(1, len(value))], ",[]{}#&*!|>'\"%@`") ||
+		(strings.ContainsAny(value[:min(1, len(value))], "-?:") && (len(value) == 1 || value[1] == ' '))
+	if value != "" && value == strings.TrimSpace(value) && !indicator &&
+		!strings.Contains(value, ": ") && !strings.Contains(value, " #") &&
+		!strings.HasSuffix(value, ":") {
+		return value
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return strconv.Quote(value)
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
 
 func (tw *Writer) Skip(description, reason string) int {
@@ -60,12 +143,28 @@ func (tw *Writer) Skip(description, reason string) int {
 	return tw.n
 }
 
+// SkipWithDiagnostics emits a skipped test point followed by a YAML
+// diagnostic block.
+func (tw *Writer) SkipWithDiagnostics(description, reason string, diagnostics map[string]string) int {
+	n := tw.Skip(description, reason)
+	tw.writeDiagnostics(diagnostics)
+	return n
+}
+
 func (tw *Writer) Todo(description, reason string) int {
 	tw.n++
 	fmt.Fprintf(tw.w, "not ok %d - %s # TODO %s\n", tw.n, description, reason)
 	return tw.n
 }
 
+// TodoPassed emits a passing test point with a TODO directive: a test that
+// was expected to fail but passed.
+func (tw *Writer) TodoPassed(description, reason string) int {
+	tw.n++
+	fmt.Fprintf(tw.w, "ok %d - %s # TODO %s\n", tw.n, description, reason)
+	return tw.n
+}
+
 func (tw *Writer) PlanAhead(n int) {
 	fmt.Fprintf(tw.w, "1..%d\n", n)
 }
@@ -74,6 +173,12 @@ func (tw *Writer) Plan() {
 	fmt.Fprintf(tw.w, "1..%d\n", tw.n)
 }
 
+// SkipAll emits a plan of zero tests with a SKIP directive, for a stream or
+// subtest that ran no tests at all.
+func (tw *Writer) SkipAll(reason string) {
+	fmt.Fprintf(tw.w, "1..0 # SKIP %s\n", reason)
+}
+
 func (tw *Writer) BailOut(reason string) {
 	fmt.Fprintf(tw.w, "Bail out! %s\n", reason)
 }
@@ -82,6 +187,12 @@ func (tw *Writer) Comment(text string) {
 	fmt.Fprintf(tw.w, "# %s\n", text)
 }
 
+// passthrough copies a line from another TAP stream verbatim, relative to
+// this writer's indentation. It does not touch the test counter.
+func (tw *Writer) passthrough(line string) {
+	fmt.Fprintln(tw.w, line)
+}
+
 type indentWriter struct {
 	w      io.Writer
 	prefix string
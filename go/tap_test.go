@@ -63,6 +63,19 @@ func TestNotOkWithDiagnostics(t *testing.T) {
 	}
 }
 
+func TestOkWithDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.OkWithDiagnostics("annotated", map[string]string{"elapsed": "0.010"})
+	out := buf.String()
+	if !strings.Contains(out, "ok 1 - annotated\n  ---\n  elapsed: 0.010\n  ...\n") {
+		t.Errorf("expected ok line with YAML block, got: %q", out)
+	}
+	if strings.Contains(out, "not ok") {
+		t.Errorf("expected passing test point, got: %q", out)
+	}
+}
+
 func TestNotOkWithMultilineDiagnostic(t *testing.T) {
 	var buf bytes.Buffer
 	tw := NewWriter(&buf)
@@ -108,6 +121,90 @@ func TestSkipEmitsDirective(t *testing.T) {
 	}
 }
 
+func TestNotOkWithLocations(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.NotOkWithLocations("TestA", map[string]string{"file": "a_test.go", "line": "9"}, []Location{
+		{File: "a_test.go", Line: "9", Message: "got 1"},
+		{File: "helper_test.go", Line: "20", Message: "want 2\ngot 3"},
+	})
+	want := "not ok 1 - TestA\n" +
+		"  ---\n" +
+		"  file: a_test.go\n" +
+		"  line: 9\n" +
+		"  locations:\n" +
+		"    - file: a_test.go\n" +
+		"      line: 9\n" +
+		"      message: got 1\n" +
+		"    - file: helper_test.go\n" +
+		"      line: 20\n" +
+		"      message: |\n" +
+		"        want 2\n" +
+		"        got 3\n" +
+		"  ...\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected locations list, got:\n%s", buf.String())
+	}
+}
+
+func TestYAMLScalarQuoting(t *testing.T) {
+	for value, want := range map[string]string{
+		"got 1":          "got 1",
+		"-1":             "-1",
+		"0.250":          "0.250",
+		"first: got 1":   "'first: got 1'",
+		"a.go # x":       "'a.go # x'",
+		"- item":         "'- item'",
+		"*ptr":           "'*ptr'",
+		`"quoted"`:       `'"quoted"'`,
+		"it's: here":     "'it''s: here'",
+		"":               "''",
+		"tab\there: yes": `"tab\there: yes"`,
+	} {
+		if got := yamlScalar(value); got != want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestQuotedYAMLReadsBack(t *testing.T) {
+	diag := map[string]string{
+		"message": "first: got 1",
+		"file":    "a.go # x",
+		"quote":   "it's: here",
+		"control": "tab\there: yes",
+	}
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.NotOk("a", diag)
+	tw.Plan()
+
+	r := NewReader(strings.NewReader(buf.String()))
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			t.Fatalf("no YAML block in:\n%s", buf.String())
+		}
+		if ev.Type == EventYAMLDiagnostic {
+			for k, v := range diag {
+				if ev.YAML[k] != v {
+					t.Errorf("YAML[%q] = %q, want %q", k, ev.YAML[k], v)
+				}
+			}
+			return
+		}
+	}
+}
+
+func TestSkipWithDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.SkipWithDiagnostics("pkg", "no test files", map[string]string{"cached": "true"})
+	if !strings.Contains(buf.String(), "ok 1 - pkg # SKIP no test files\n  ---\n  cached: true\n  ...\n") {
+		t.Errorf("expected skip line with YAML block, got: %q", buf.String())
+	}
+}
+
 func TestTodoEmitsDirective(t *testing.T) {
 	var buf bytes.Buffer
 	tw := NewWriter(&buf)
@@ -120,6 +217,16 @@ func TestTodoEmitsDirective(t *testing.T) {
 	}
 }
 
+func TestTodoPassedEmitsOkWithDirective(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.TodoPassed("flaky", "expected to fail")
+	out := buf.String()
+	if !strings.Contains(out, "ok 1 - flaky # TODO expected to fail\n") || strings.Contains(out, "not ok") {
+		t.Errorf("expected passing TODO line, got: %q", out)
+	}
+}
+
 func TestPlanAhead(t *testing.T) {
 	var buf bytes.Buffer
 	tw := NewWriter(&buf)
@@ -150,6 +257,19 @@ func TestPlanWithZeroTests(t *testing.T) {
 	}
 }
 
+func TestSkipAll(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.SkipAll("no tests to run")
+	if !strings.HasSuffix(buf.String(), "1..0 # SKIP no tests to run\n") {
+		t.Errorf("expected skip-all plan, got: %q", buf.String())
+	}
+	r := NewReader(strings.NewReader(buf.String()))
+	if !r.Summary().Valid {
+		t.Errorf("expected skip-all stream to be valid: %v", r.Diagnostics())
+	}
+}
+
 func TestBailOut(t *testing.T) {
 	var buf bytes.Buffer
 	tw := NewWriter(&buf)
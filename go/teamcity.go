@@ -0,0 +1,232 @@
+package tap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type teamcitySuite struct {
+	name   string
+	failed bool
+}
+
+type teamcityPoint struct {
+	point *TestPointResult
+	depth int
+	yaml  map[string]string
+}
+
+// teamcityWriter turns a stream of TAP events into TeamCity service
+// messages. Subtests are suites; the test point closing a subtest only
+// finishes its suite.
+type teamcityWriter struct {
+	w   io.Writer
+	err error
+
+	// stack[i] is the open suite at depth i+1.
+	stack []*teamcitySuite
+	// closed[d] is a suite at depth d+1 whose lines have ended and which
+	// awaits its closing test point at depth d.
+	closed map[int]*teamcitySuite
+	// pending is the last test point, held until its YAML block, if any,
+	// has been read.
+	pending *teamcityPoint
+
+	subtestName  string
+	subtestDepth int
+}
+
+// WriteTeamCity reads a TAP stream and writes TeamCity service messages to
+// w as events arrive: testSuiteStarted and testSuiteFinished around each
+// subtest, and testStarted, testFailed or testIgnored, and testFinished for
+// each test point. Failures carry their YAML diagnostics as details. A bail
+// out becomes a buildProblem, and protocol diagnostics become messages
+// once the stream ends.
+func WriteTeamCity(r *Reader, w io.Writer) error {
+	tw := &teamcityWriter{w: w, closed: make(map[int]*teamcitySuite)}
+
+	for {
+		ev, err := r.Next()
+		if err != nil {
+			break
+		}
+
+		if ev.Type == EventYAMLDiagnostic && tw.pending != nil {
+			tw.pending.yaml = ev.YAML
+			tw.flush()
+			continue
+		}
+		tw.flush()
+
+		if ev.Type == EventComment {
+			if name, ok := strings.CutPrefix(ev.Comment, "Subtest:"); ok {
+				// "# Subtest:" is written either indented with the
+				// subtest's own lines or at the parent's depth.
+				tw.subtestName = strings.TrimSpace(name)
+				tw.subtestDepth = ev.Depth
+				if ev.Depth <= len(tw.stack) {
+					tw.subtestDepth = ev.Depth + 1
+				}
+			}
+			continue
+		}
+
+		tw.setDepth(ev.Depth)
+
+		switch ev.Type {
+		case EventTestPoint:
+			tw.pending = &teamcityPoint{point: ev.TestPoint, depth: ev.Depth}
+		case EventBailOut:
+			tw.finishClosed(ev.Depth)
+			tw.message("buildProblem", "description", strings.TrimSpace("Bail out! "+ev.BailOut.Reason))
+			tw.markFailed()
+		default:
+			tw.finishClosed(ev.Depth)
+		}
+	}
+
+	tw.flush()
+	tw.setDepth(0)
+	tw.finishClosed(0)
+
+	for _, d := range r.Diagnostics() {
+		status := "WARNING"
+		if d.Severity == SeverityError {
+			status = "ERROR"
+		}
+		tw.message("message", "text", fmt.Sprintf("TAP line %d: [%s] %s", d.Line, d.Rule, d.Message), "status", status)
+	}
+	return tw.err
+}
+
+// setDepth opens and closes suites until depth is the current depth.
+// Suites that close are held until their closing test point arrives.
+func (tw *teamcityWriter) setDepth(depth int) {
+	for depth < len(tw.stack) {
+		suite := tw.stack[len(tw.stack)-1]
+		tw.stack = tw.stack[:len(tw.stack)-1]
+		parent := len(tw.stack)
+		// A suite already waiting at this depth never got its closing
+		// test point.
+		tw.finishClosed(parent)
+		tw.closed[parent] = suite
+	}
+	for depth > len(tw.stack) {
+		tw.finishClosed(len(tw.stack))
+		name := "subtest"
+		if tw.subtestName != "" && tw.subtestDepth == len(tw.stack)+1 {
+			name = tw.subtestName
+		}
+		tw.subtestName = ""
+		tw.stack = append(tw.stack, &teamcitySuite{name: name})
+		tw.message("testSuiteStarted", "name", name)
+	}
+}
+
+// finishClosed finishes the suite awaiting a closing test point at depth,
+// if any, without one.
+func (tw *teamcityWriter) finishClosed(depth int) {
+	if suite := tw.closed[depth]; suite != nil {
+		delete(tw.closed, depth)
+		tw.finishSuite(suite, nil)
+	}
+}
+
+func (tw *teamcityWriter) finishSuite(suite *teamcitySuite, closing *teamcityPoint) {
+	// The subtest's own failure is reported as a test of its own.
+	if closing != nil && failedOnItsOwn(closing.point, suite.failed) {
+		tw.message("testStarted", "name", suite.name)
+		tw.testFailed(suite.name, closing.yaml)
+		tw.message("testFinished", "name", suite.name)
+		suite.failed = true
+	}
+	tw.message("testSuiteFinished", "name", suite.name)
+	if suite.failed {
+		tw.markFailed()
+	}
+}
+
+// flush emits the pending test point, either as a test or as the closing
+// test point of a subtest.
+func (tw *teamcityWriter) flush() {
+	p := tw.pending
+	if p == nil {
+		return
+	}
+	tw.pending = nil
+
+	if suite := tw.closed[p.depth]; suite != nil {
+		delete(tw.closed, p.depth)
+		tw.finishSuite(suite, p)
+		return
+	}
+
+	name := p.point.Description
+	if name == "" {
+		name = fmt.Sprintf("test %d", p.point.Number)
+	}
+
+	tw.message("testStarted", "name", name)
+	switch {
+	case p.point.Directive == DirectiveSkip:
+		tw.message("testIgnored", "name", name, "message", p.point.Reason)
+	case p.point.Directive == DirectiveTodo:
+		tw.message("testIgnored", "name", name, "message", strings.TrimSpace("TODO "+p.point.Reason))
+	case !p.point.OK:
+		tw.testFailed(name, p.yaml)
+		tw.markFailed()
+	}
+	if elapsed, ok := yamlDuration(p.yaml); ok {
+		tw.message("testFinished", "name", name, "duration", fmt.Sprintf("%d", int64(elapsed*1000)))
+	} else {
+		tw.message("testFinished", "name", name)
+	}
+}
+
+func (tw *teamcityWriter) testFailed(name string, yaml map[string]string) {
+	message := firstNonEmpty(yaml["message"], yaml["output"], "not ok")
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	tw.message("testFailed", "name", name, "message", message, "details", formatYAMLBody(yaml))
+}
+
+// markFailed marks the innermost open suite as containing a failure.
+func (tw *teamcityWriter) markFailed() {
+	if len(tw.stack) > 0 {
+		tw.stack[len(tw.stack)-1].failed = true
+	}
+}
+
+// message writes a service message with the given attribute name and value
+// pairs.
+func (tw *teamcityWriter) message(kind string, attrs ...string) {
+	if tw.err != nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("##teamcity[" + kind)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&b, " %s='%s'", attrs[i], escapeTeamCity(attrs[i+1]))
+	}
+	b.WriteString("]\n")
+	_, tw.err = io.WriteString(tw.w, b.String())
+}
+
+var teamcityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"[", "|[",
+	"]", "|]",
+	"\n", "|n",
+	"\r", "|r",
+	"\u0085", "|x",
+	"\u2028", "|l",
+	"\u2029", "|p",
+)
+
+// escapeTeamCity escapes a service message attribute value.
+func escapeTeamCity(s string) string {
+	return teamcityEscaper.Replace(s)
+}
@@ -0,0 +1,83 @@
+package tap
+
+import (
+	"strings"
+	"testing"
+)
+
+func writeTeamCityString(t *testing.T, input string) string {
+	t.Helper()
+	var buf strings.Builder
+	if err := WriteTeamCity(NewReader(strings.NewReader(input)), &buf); err != nil {
+		t.Fatalf("WriteTeamCity error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriteTeamCitySubtests(t *testing.T) {
+	var in strings.Builder
+	tw := NewWriter(&in)
+	sub := tw.Subtest("example.com/pkg")
+	sub.Ok("TestA")
+	sub.NotOk("TestB", map[string]string{"message": "got 1\nwant 2", "elapsed": "0.250"})
+	sub.Skip("TestC", "needs network")
+	sub.Plan()
+	tw.NotOk("example.com/pkg", nil)
+	tw.Plan()
+
+	want := `##teamcity[testSuiteStarted name='example.com/pkg']
+##teamcity[testStarted name='TestA']
+##teamcity[testFinished name='TestA']
+##teamcity[testStarted name='TestB']
+##teamcity[testFailed name='TestB' message='got 1' details='elapsed: 0.250|nmessage:|n  got 1|n  want 2|n']
+##teamcity[testFinished name='TestB' duration='250']
+##teamcity[testStarted name='TestC']
+##teamcity[testIgnored name='TestC' message='needs network']
+##teamcity[testFinished name='TestC']
+##teamcity[testSuiteFinished name='example.com/pkg']
+`
+	if got := writeTeamCityString(t, in.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTeamCityFailedSubtestWithoutFailingTests(t *testing.T) {
+	input := `TAP version 14
+# Subtest: pkg
+    1..1
+    ok 1 - TestA
+not ok 1 - pkg
+  ---
+  message: panic: boom
+  ...
+1..1
+`
+	got := writeTeamCityString(t, input)
+
+	want := "##teamcity[testStarted name='TestA']\n##teamcity[testFinished name='TestA']\n" +
+		"##teamcity[testStarted name='pkg']\n##teamcity[testFailed name='pkg' message='panic: boom' details='message: panic: boom|n']\n" +
+		"##teamcity[testFinished name='pkg']\n##teamcity[testSuiteFinished name='pkg']\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("expected failure reported inside the suite, got:\n%s", got)
+	}
+}
+
+func TestWriteTeamCityBailOutAndDiagnostics(t *testing.T) {
+	got := writeTeamCityString(t, "TAP version 14\n1..3\nok 1 - a\nBail out! db [down]\n")
+
+	if !strings.Contains(got, "##teamcity[buildProblem description='Bail out! db |[down|]']\n") {
+		t.Errorf("expected escaped buildProblem, got:\n%s", got)
+	}
+
+	got = writeTeamCityString(t, "TAP version 14\n1..2\nok 1 - a\n")
+	if !strings.Contains(got, "##teamcity[message text='TAP line 3: |[plan-count-mismatch|] plan declared 2 tests but 1 ran' status='ERROR']") {
+		t.Errorf("expected protocol diagnostic message, got:\n%s", got)
+	}
+}
+
+func TestEscapeTeamCity(t *testing.T) {
+	got := escapeTeamCity("it's a|b [x]\r\n\u2028")
+	if want := "it|'s a||b |[x|]|r|n|l"; got != want {
+		t.Errorf("escapeTeamCity = %q, want %q", got, want)
+	}
+}
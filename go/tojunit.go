@@ -0,0 +1,198 @@
+package tap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type junitOutSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []*junitOutSuite `xml:"testsuite"`
+}
+
+type junitOutSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []*junitOutCase `xml:"testcase"`
+
+	// caseTime is the total of the suite's testcase times, and elapsed
+	// the suite's own time.
+	caseTime, elapsed float64
+}
+
+type junitOutCase struct {
+	Name      string           `xml:"name,attr"`
+	Classname string           `xml:"classname,attr"`
+	Time      string           `xml:"time,attr"`
+	File      string           `xml:"file,attr,omitempty"`
+	Line      string           `xml:"line,attr,omitempty"`
+	Failure   *junitOutProblem `xml:"failure,omitempty"`
+	Error     *junitOutProblem `xml:"error,omitempty"`
+	Skipped   *junitOutProblem `xml:"skipped,omitempty"`
+}
+
+type junitOutProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+// WriteJUnit reads a TAP stream and writes it to w as JUnit XML. Each
+// top-level subtest becomes a testsuite whose testcases are the leaf test
+// points beneath it, named by their path within the subtest; top-level
+// test points outside any subtest go into a suite called name. Durations
+// come from the elapsed, duration or duration_ms YAML keys; a suite takes
+// its subtest's own duration if it has one and otherwise the total of its
+// testcases. The YAML diagnostics of failing tests become the <failure>
+// body.
+func WriteJUnit(r *Reader, w io.Writer, name string) error {
+	root := buildTapTree(r, nil)
+
+	doc := junitOutSuites{Name: name}
+	var loose *junitOutSuite
+	for _, node := range root.children {
+		if len(node.children) == 0 {
+			if loose == nil {
+				loose = &junitOutSuite{Name: name}
+				doc.Suites = append(doc.Suites, loose)
+			}
+			addJUnitCases(loose, node, "")
+			continue
+		}
+		suite := &junitOutSuite{Name: node.name}
+		for _, child := range node.children {
+			addJUnitCases(suite, child, "")
+		}
+		if failedOnItsOwn(node.point, suite.Failures+suite.Errors > 0) {
+			addJUnitCases(suite, &tapNode{name: node.name, point: node.point, yaml: node.yaml}, "")
+		}
+		suite.elapsed = suite.caseTime
+		if elapsed, ok := yamlDuration(node.yaml); ok {
+			suite.elapsed = elapsed
+		}
+		doc.Suites = append(doc.Suites, suite)
+	}
+	if loose != nil {
+		loose.elapsed = loose.caseTime
+	}
+
+	var total float64
+	for _, s := range doc.Suites {
+		s.Time = fmt.Sprintf("%.3f", s.elapsed)
+		total += s.elapsed
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Errors += s.Errors
+		doc.Skipped += s.Skipped
+	}
+	doc.Time = fmt.Sprintf("%.3f", total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func addJUnitCases(suite *junitOutSuite, node *tapNode, prefix string) {
+	name := prefix + node.name
+	if len(node.children) > 0 {
+		for _, child := range node.children {
+			addJUnitCases(suite, child, name+"/")
+		}
+		return
+	}
+
+	tc := &junitOutCase{Name: name, Classname: suite.Name, Time: "0.000"}
+	suite.Cases = append(suite.Cases, tc)
+	suite.Tests++
+
+	if node.bailOut != "" || node.point == nil {
+		tc.Error = &junitOutProblem{Message: "Bail out! " + node.bailOut, Type: "bail-out"}
+		suite.Errors++
+		return
+	}
+
+	if elapsed, ok := yamlDuration(node.yaml); ok {
+		tc.Time = fmt.Sprintf("%.3f", elapsed)
+		suite.caseTime += elapsed
+	}
+	tc.File = node.yaml["file"]
+	tc.Line = node.yaml["line"]
+
+	tp := node.point
+	switch {
+	case tp.Directive == DirectiveSkip:
+		tc.Skipped = &junitOutProblem{Message: tp.Reason}
+		suite.Skipped++
+	case tp.Directive == DirectiveTodo:
+		tc.Skipped = &junitOutProblem{Message: strings.TrimSpace("TODO " + tp.Reason)}
+		suite.Skipped++
+	case !tp.OK:
+		message := firstNonEmpty(node.yaml["message"], tp.Description)
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			message = message[:i]
+		}
+		tc.Failure = &junitOutProblem{
+			Message: message,
+			Type:    firstNonEmpty(node.yaml["type"], "failure"),
+			Body:    formatYAMLBody(node.yaml),
+		}
+		suite.Failures++
+	}
+}
+
+func yamlDuration(yaml map[string]string) (float64, bool) {
+	for _, key := range []string{"elapsed", "duration"} {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(yaml[key], "s"), 64); err == nil {
+			return v, true
+		}
+	}
+	if v, err := strconv.ParseFloat(yaml["duration_ms"], 64); err == nil {
+		return v / 1000, true
+	}
+	return 0, false
+}
+
+// formatYAMLBody renders YAML diagnostics as readable text, keys sorted,
+// with multi-line values indented beneath their key.
+func formatYAMLBody(yaml map[string]string) string {
+	keys := make([]string, 0, len(yaml))
+	for k := range yaml {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := yaml[k]
+		if strings.Contains(v, "\n") {
+			fmt.Fprintf(&b, "%s:\n", k)
+			for _, line := range strings.Split(v, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", k, v)
+		}
+	}
+	return b.String()
+}
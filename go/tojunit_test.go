@@ -0,0 +1,147 @@
+package tap
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnitFromGoTestOutput(t *testing.T) {
+	input := strings.Join([]string{
+		"TAP version 14",
+		"    # Subtest: example.com/foo",
+		"    ok 1 - TestA",
+		"        # Subtest: TestParent",
+		"        not ok 1 - child",
+		"          ---",
+		"          elapsed: 0.250",
+		"          file: foo_test.go",
+		"          line: 12",
+		"          message: |",
+		"            foo_test.go:12: expected 1",
+		"            got 2",
+		"          ...",
+		"        1..1",
+		"    not ok 2 - TestParent",
+		"    ok 3 - TestSkip # SKIP not applicable",
+		"    not ok 4 - TestLater # TODO not done",
+		"    1..4",
+		"not ok 1 - example.com/foo",
+		"ok 2 - loose",
+		"1..2",
+	}, "\n") + "\n"
+
+	var buf strings.Builder
+	if err := WriteJUnit(NewReader(strings.NewReader(input)), &buf, "tap"); err != nil {
+		t.Fatalf("WriteJUnit error: %v", err)
+	}
+	out := buf.String()
+
+	var doc junitSuite
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out)
+	}
+	if len(doc.Suites) != 2 {
+		t.Fatalf("expected 2 suites, got %d:\n%s", len(doc.Suites), out)
+	}
+
+	foo := doc.Suites[0]
+	if foo.Name != "example.com/foo" {
+		t.Errorf("expected suite named after subtest, got %q", foo.Name)
+	}
+	if len(foo.Cases) != 4 {
+		t.Fatalf("expected 4 leaf testcases, got %d:\n%s", len(foo.Cases), out)
+	}
+	child := foo.Cases[1]
+	if child.Name != "TestParent/child" {
+		t.Errorf("expected nested path as testcase name, got %q", child.Name)
+	}
+	if child.Time != "0.250" || child.File != "foo_test.go" || child.Line != "12" {
+		t.Errorf("expected time and location from YAML, got %+v", child)
+	}
+	if len(child.Failures) != 1 || child.Failures[0].Message != "foo_test.go:12: expected 1" {
+		t.Fatalf("expected failure with first message line, got %+v", child.Failures)
+	}
+	if !strings.Contains(child.Failures[0].Text, "message:\n  foo_test.go:12: expected 1\n  got 2\n") {
+		t.Errorf("expected YAML diagnostics in failure body, got %q", child.Failures[0].Text)
+	}
+	if foo.Cases[2].Skipped == nil || foo.Cases[2].Skipped.Message != "not applicable" {
+		t.Errorf("expected SKIP as skipped, got %+v", foo.Cases[2])
+	}
+	if foo.Cases[3].Skipped == nil || foo.Cases[3].Skipped.Message != "TODO not done" {
+		t.Errorf("expected TODO as skipped, got %+v", foo.Cases[3])
+	}
+
+	if doc.Suites[1].Name != "tap" || len(doc.Suites[1].Cases) != 1 {
+		t.Errorf("expected loose test points in default suite:\n%s", out)
+	}
+	if !strings.Contains(out, `<testsuites name="tap" tests="5" failures="1" errors="0" skipped="2"`) {
+		t.Errorf("expected totals on testsuites:\n%s", out)
+	}
+}
+
+func TestWriteJUnitFailedSubtestWithoutFailingChildren(t *testing.T) {
+	input := "TAP version 14\n    # Subtest: pkg\n    ok 1 - TestA\n    1..1\nnot ok 1 - pkg\n1..1\n"
+
+	var buf strings.Builder
+	if err := WriteJUnit(NewReader(strings.NewReader(input)), &buf, "tap"); err != nil {
+		t.Fatalf("WriteJUnit error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<testcase name="pkg" classname="pkg"`) {
+		t.Errorf("expected synthetic failing testcase:\n%s", buf.String())
+	}
+}
+
+func TestWriteJUnitSuiteTime(t *testing.T) {
+	input := strings.Join([]string{
+		"TAP version 14",
+		"    # Subtest: timed",
+		"    ok 1 - a",
+		"      ---",
+		"      elapsed: 0.100",
+		"      ...",
+		"    1..1",
+		"ok 1 - timed",
+		"  ---",
+		"  elapsed: 0.500",
+		"  ...",
+		"    # Subtest: untimed",
+		"    ok 1 - a",
+		"      ---",
+		"      elapsed: 0.100",
+		"      ...",
+		"    ok 2 - b",
+		"      ---",
+		"      elapsed: 0.200",
+		"      ...",
+		"    1..2",
+		"ok 2 - untimed",
+		"1..2",
+	}, "\n") + "\n"
+
+	var buf strings.Builder
+	if err := WriteJUnit(NewReader(strings.NewReader(input)), &buf, "tap"); err != nil {
+		t.Fatalf("WriteJUnit error: %v", err)
+	}
+	var doc junitSuite
+	if err := xml.Unmarshal([]byte(buf.String()), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	// A suite's own time wins over its testcases', which are otherwise
+	// added up.
+	if doc.Suites[0].Time != "0.500" || doc.Suites[1].Time != "0.300" || doc.Time != "0.800" {
+		t.Errorf("suite times = %q, %q, total %q", doc.Suites[0].Time, doc.Suites[1].Time, doc.Time)
+	}
+}
+
+func TestWriteJUnitBailOut(t *testing.T) {
+	input := "TAP version 14\n1..2\nok 1 - a\nBail out! db down\n"
+
+	var buf strings.Builder
+	if err := WriteJUnit(NewReader(strings.NewReader(input)), &buf, "tap"); err != nil {
+		t.Fatalf("WriteJUnit error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<error message="Bail out! db down" type="bail-out">`) {
+		t.Errorf("expected bail out as error:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,122 @@
+package tap
+
+import "fmt"
+
+// Reason identifies one cause behind a harness verdict.
+type Reason int
+
+const (
+	ReasonTestsFailed Reason = iota
+	ReasonPlanMismatch
+	ReasonPlanMissing
+	ReasonBailedOut
+	ReasonProtocolInvalid
+	ReasonExitStatus
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonTestsFailed:
+		return "tests-failed"
+	case ReasonPlanMismatch:
+		return "plan-mismatch"
+	case ReasonPlanMissing:
+		return "plan-missing"
+	case ReasonBailedOut:
+		return "bailed-out"
+	case ReasonProtocolInvalid:
+		return "protocol-invalid"
+	case ReasonExitStatus:
+		return "exit-status"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes a Reason as its stable string code.
+func (r Reason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a Reason from its string code.
+func (r *Reason) UnmarshalText(text []byte) error {
+	for c := ReasonTestsFailed; c <= ReasonExitStatus; c++ {
+		if c.String() == string(text) {
+			*r = c
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown reason: %q", text)
+}
+
+// Exit codes for harness verdicts. Where several reasons apply, the most
+// severe one wins: a bail out outranks a broken stream, which outranks
+// failing tests.
+const (
+	ExitPassed          = 0
+	ExitTestsFailed     = 1
+	ExitProtocolInvalid = 2
+	ExitBailedOut       = 3
+)
+
+// Verdict is the harness's pass/fail determination for a TAP stream, per
+// the "Failure Determination" section of the TAP-14 specification.
+type Verdict struct {
+	Passed  bool     `json:"passed"`
+	Reasons []Reason `json:"reasons,omitempty"`
+}
+
+// ExitCode maps the verdict to one of the Exit* codes.
+func (v Verdict) ExitCode() int {
+	code := ExitPassed
+	for _, r := range v.Reasons {
+		switch r {
+		case ReasonBailedOut:
+			return ExitBailedOut
+		case ReasonPlanMismatch, ReasonPlanMissing, ReasonProtocolInvalid:
+			code = ExitProtocolInvalid
+		case ReasonTestsFailed, ReasonExitStatus:
+			if code < ExitTestsFailed {
+				code = ExitTestsFailed
+			}
+		}
+	}
+	return code
+}
+
+// Verdict consumes the rest of the stream and determines whether a harness
+// should treat it as failed: a failing test point (not TODO), a plan that
+// disagrees with the tests run, a missing plan, a bail out, or any other
+// error-severity protocol problem.
+func (r *Reader) Verdict() Verdict {
+	summary := r.Summary()
+
+	var reasons []Reason
+	if summary.Failed > 0 {
+		reasons = append(reasons, ReasonTestsFailed)
+	}
+
+	seen := make(map[Reason]bool)
+	for _, d := range r.diags {
+		if d.Severity != SeverityError {
+			continue
+		}
+		reason := ReasonProtocolInvalid
+		switch d.Rule {
+		case "plan-count-mismatch":
+			reason = ReasonPlanMismatch
+		case "plan-required":
+			reason = ReasonPlanMissing
+		}
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if summary.BailedOut {
+		reasons = append(reasons, ReasonBailedOut)
+	}
+
+	return Verdict{Passed: len(reasons) == 0, Reasons: reasons}
+}
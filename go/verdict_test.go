@@ -0,0 +1,73 @@
+package tap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func verdictFor(input string) Verdict {
+	return NewReader(strings.NewReader(input)).Verdict()
+}
+
+func TestVerdictPassed(t *testing.T) {
+	v := verdictFor("TAP version 14\n1..2\nok 1 - a\nnot ok 2 - b # TODO later\n")
+	if !v.Passed {
+		t.Errorf("expected passed verdict, got reasons %v", v.Reasons)
+	}
+	if v.ExitCode() != ExitPassed {
+		t.Errorf("expected exit code %d, got %d", ExitPassed, v.ExitCode())
+	}
+}
+
+func TestVerdictReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		reason Reason
+		exit   int
+	}{
+		{"failed", "TAP version 14\n1..1\nnot ok 1 - a\n", ReasonTestsFailed, ExitTestsFailed},
+		{"mismatch", "TAP version 14\n1..2\nok 1 - a\n", ReasonPlanMismatch, ExitProtocolInvalid},
+		{"missing", "TAP version 14\nok 1 - a\n", ReasonPlanMissing, ExitProtocolInvalid},
+		{"invalid", "1..1\nok 1 - a\n", ReasonProtocolInvalid, ExitProtocolInvalid},
+		{"bailed", "TAP version 14\n1..2\nok 1 - a\nBail out! db down\n", ReasonBailedOut, ExitBailedOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := verdictFor(tt.input)
+			if v.Passed {
+				t.Fatal("expected failed verdict")
+			}
+			found := false
+			for _, r := range v.Reasons {
+				if r == tt.reason {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected reason %s in %v", tt.reason, v.Reasons)
+			}
+			if v.ExitCode() != tt.exit {
+				t.Errorf("expected exit code %d, got %d", tt.exit, v.ExitCode())
+			}
+		})
+	}
+}
+
+func TestVerdictMostSevereExitCodeWins(t *testing.T) {
+	v := verdictFor("TAP version 14\n1..3\nnot ok 1 - a\nBail out!\n")
+	if v.ExitCode() != ExitBailedOut {
+		t.Errorf("expected exit code %d, got %d", ExitBailedOut, v.ExitCode())
+	}
+}
+
+func TestVerdictJSONUsesReasonCodes(t *testing.T) {
+	data, err := json.Marshal(verdictFor("TAP version 14\n1..1\nnot ok 1 - a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"passed":false,"reasons":["tests-failed"]}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}